@@ -0,0 +1,195 @@
+// Package cassette ghi lại và phát lại phản hồi HTTP để phát triển selector
+// và chạy test đầu-cuối mà không cần gọi tới site thật.
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mode là chế độ của Transport.
+type Mode string
+
+const (
+	// Off gọi mạng bình thường
+	Off Mode = "off"
+	// Record gọi mạng và lưu phản hồi vào thư mục cassette
+	Record Mode = "record"
+	// Replay chỉ phát lại phản hồi đã lưu, không gọi mạng
+	Replay Mode = "replay"
+	// Cache phát lại phản hồi đã lưu nếu chưa quá MaxAge, không thì gọi mạng và lưu lại
+	Cache Mode = "cache"
+)
+
+// ErrNotRecorded báo request chưa có trong cassette khi ở chế độ Replay.
+var ErrNotRecorded = errors.New("cassette: request chưa được ghi")
+
+// ParseMode đọc chế độ từ chuỗi, rỗng là Off.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "", Off:
+		return Off, nil
+	case Record, Replay, Cache:
+		return m, nil
+	default:
+		return "", fmt.Errorf("chế độ cassette không hợp lệ: %q (off|record|replay|cache)", s)
+	}
+}
+
+// Transport là http.RoundTripper ghi/phát lại phản hồi trong Dir.
+// Mỗi request (method + url) ứng với hai file <key>.json (trạng thái, header)
+// và <key>.body (nội dung) để dễ xem và sửa tay.
+type Transport struct {
+	Dir  string
+	Mode Mode
+	// Next là transport thật dùng khi Record, Cache hoặc Off, nil là http.DefaultTransport
+	Next http.RoundTripper
+	// MaxAge là tuổi tối đa của phản hồi đã lưu ở chế độ Cache, 0 là không hết hạn
+	MaxAge time.Duration
+
+	mu sync.Mutex
+}
+
+// New tạo Transport bọc next.
+func New(dir string, mode Mode, next http.RoundTripper) *Transport {
+	return &Transport{Dir: dir, Mode: mode, Next: next}
+}
+
+type meta struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+}
+
+// RoundTrip thực hiện request theo Mode.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch t.Mode {
+	case Replay:
+		return t.replay(req)
+	case Record:
+		return t.record(req)
+	case Cache:
+		if t.fresh(req) {
+			return t.replay(req)
+		}
+		return t.record(req)
+	default:
+		return t.next().RoundTrip(req)
+	}
+}
+
+func (t *Transport) next() http.RoundTripper {
+	if t.Next != nil {
+		return t.Next
+	}
+	return http.DefaultTransport
+}
+
+// fresh cho biết request đã được lưu và chưa quá MaxAge.
+func (t *Transport) fresh(req *http.Request) bool {
+	info, err := os.Stat(filepath.Join(t.Dir, Key(req)) + ".json")
+	if err != nil {
+		return false
+	}
+	return t.MaxAge <= 0 || time.Since(info.ModTime()) < t.MaxAge
+}
+
+func (t *Transport) replay(req *http.Request) (*http.Response, error) {
+	base := filepath.Join(t.Dir, Key(req))
+	data, err := os.ReadFile(base + ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, req.URL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m meta
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("cassette %s.json: %w", base, err)
+	}
+	body, err := os.ReadFile(base + ".body")
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", m.Status, http.StatusText(m.Status)),
+		StatusCode:    m.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        m.Header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (t *Transport) record(req *http.Request) (*http.Response, error) {
+	resp, err := t.next().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// 304 chỉ đúng với header điều kiện của request này, key không tính header nên không lưu
+	if resp.StatusCode == http.StatusNotModified {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	// nội dung đã được giải nén khi đọc, bỏ các header không còn đúng
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+	header.Del("Set-Cookie")
+	var data bytes.Buffer
+	enc := json.NewEncoder(&data)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(meta{Method: req.Method, URL: req.URL.String(), Status: resp.StatusCode, Header: header}); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := os.MkdirAll(t.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	base := filepath.Join(t.Dir, Key(req))
+	if err := os.WriteFile(base+".json", data.Bytes(), 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(base+".body", body, 0o644); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+var unsafeRe = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// Key trả về tên file (không có phần mở rộng) của request: host và đường dẫn
+// dễ đọc cộng với hash của method và url đầy đủ để không trùng.
+func Key(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.String()))
+	slug := strings.Trim(unsafeRe.ReplaceAllString(req.URL.Host+req.URL.Path, "-"), "-")
+	if len(slug) > 80 {
+		slug = slug[:80]
+	}
+	return slug + "-" + hex.EncodeToString(sum[:])[:12]
+}
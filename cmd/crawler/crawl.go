@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"webcrawler/config"
+	"webcrawler/documents"
+	"webcrawler/feed"
+	"webcrawler/healthcheck"
+	"webcrawler/ics"
+	"webcrawler/logging"
+	"webcrawler/metrics"
+	"webcrawler/sites"
+)
+
+func runCrawl(ctx context.Context, args []string) error {
+	fs := newFlagSet("crawl")
+	siteNames := fs.String("site", "", "tên site cần crawl, phân tách bằng dấu phẩy")
+	dryRun := fs.Bool("dry-run", false, "chạy thử: không gửi email, không ghi database")
+	previewPath := fs.String("preview", "", "khi chạy thử, ghi các email sẽ gửi ra file (.json hoặc .html, - là stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *previewPath != "" && !*dryRun {
+		return usagef("--preview chỉ dùng cùng --dry-run")
+	}
+
+	targets, err := selectSites(*siteNames)
+	if err != nil {
+		return err
+	}
+	opts := sites.Options{DryRun: *dryRun}
+	if *dryRun {
+		opts.Preview = &sites.Preview{}
+	}
+	// chạy thử không ping để không làm sai trạng thái giám sát
+	var check healthcheck.Check
+	if !*dryRun {
+		check = healthcheck.Check(config.GetEnv("HEALTHCHECK_URL", ""))
+	}
+	check.Start(ctx)
+	results, err := crawlOnce(ctx, targets, opts, *previewPath)
+	check.Finish(ctx, err, sites.Summary(results))
+	if path := config.GetEnv("METRICS_TEXTFILE", ""); path != "" && !*dryRun {
+		if werr := metrics.WriteFile(path); werr != nil {
+			slog.Warn("không ghi được file metric", "path", path, "error", werr)
+		}
+	}
+	return err
+}
+
+// crawlOnce crawl các site rồi gửi nhắc hạn, ghi lịch (hoặc ghi bản xem trước khi chạy thử).
+func crawlOnce(ctx context.Context, targets []*sites.Site, opts sites.Options, previewPath string) ([]sites.SiteResult, error) {
+	if err := initDB(); err != nil {
+		if !opts.DryRun {
+			return nil, err
+		}
+		slog.Warn("chạy thử không có database, mọi link được coi là chưa gửi", "error", err)
+	} else if !opts.DryRun {
+		if err := migrateDB(); err != nil {
+			return nil, err
+		}
+	}
+
+	var failed []error
+	results, err := sites.Run(ctx, targets, opts)
+	if err != nil {
+		failed = append(failed, err)
+	}
+
+	if opts.DryRun {
+		if err := writePreview(opts.Preview, previewPath); err != nil {
+			failed = append(failed, err)
+		}
+	} else {
+		if err := sites.SendDueReminders(ctx); err != nil {
+			failed = append(failed, fmt.Errorf("lỗi gửi nhắc hạn: %w", err))
+		}
+		if err := writeCalendar(); err != nil {
+			failed = append(failed, err)
+		}
+		if err := writeFeeds(); err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) > 0 {
+		return results, fmt.Errorf("%d lỗi trong lần chạy: %w", len(failed), errors.Join(failed...))
+	}
+	return results, nil
+}
+
+// writePreview ghi các email của lần chạy thử ra path (.html là HTML, còn lại JSON),
+// path rỗng thì chỉ in danh sách tiêu đề.
+func writePreview(p *sites.Preview, path string) error {
+	switch path {
+	case "":
+		items := p.Items()
+		fmt.Printf("🧪 %d email sẽ được gửi\n", len(items))
+		for _, n := range items {
+			fmt.Printf("- [%s] %s\n  %s\n", n.Site, n.Subject, n.URL)
+		}
+		return nil
+	case "-":
+		return p.WriteJSON(os.Stdout)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("không tạo được file %s: %w", path, err)
+	}
+	defer f.Close()
+	if strings.HasSuffix(strings.ToLower(path), ".html") {
+		err = p.WriteHTML(f)
+	} else {
+		err = p.WriteJSON(f)
+	}
+	if err != nil {
+		return fmt.Errorf("lỗi ghi %s: %w", path, err)
+	}
+	slog.Info("đã ghi email xem trước", "count", len(p.Items()), "path", path)
+	return nil
+}
+
+// selectSites trả về các site theo danh sách tên, hoặc mọi site đang bật nếu names rỗng.
+// Site bị tắt vẫn chạy được khi chỉ định rõ tên.
+func selectSites(names string) ([]*sites.Site, error) {
+	if names == "" {
+		var enabled []*sites.Site
+		for _, s := range sites.All() {
+			if !s.Disabled {
+				enabled = append(enabled, s)
+			}
+		}
+		return enabled, nil
+	}
+	var out []*sites.Site
+	for _, name := range splitList(names) {
+		s := sites.Find(name)
+		if s == nil {
+			return nil, usagef("không có site %q, xem crawler list-sites", name)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func runListSites(ctx context.Context, args []string) error {
+	if err := parseFlags(newFlagSet("list-sites"), args); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SITE\tBẬT\tLỊCH\tTUỔI TỐI ĐA\tKHÔNG CÓ NGÀY\tDANH SÁCH")
+	for _, s := range sites.All() {
+		enabled := "có"
+		if s.Disabled {
+			enabled = "không"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", s.Name, enabled, s.Schedule, s.MaxAgeDays, s.Undated, s.ListURL())
+	}
+	return w.Flush()
+}
+
+func runResend(ctx context.Context, args []string) error {
+	fs := newFlagSet("resend")
+	dryRun := fs.Bool("dry-run", false, "chạy thử: in email sẽ gửi, không gửi và không ghi database")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("cần đúng một url")
+	}
+	url := fs.Arg(0)
+	site := sites.FindByURL(url)
+	if site == nil {
+		return fmt.Errorf("không có site nào ứng với %s", url)
+	}
+	if err := initDB(); err != nil {
+		return err
+	}
+	opts := sites.Options{DryRun: *dryRun}
+	if *dryRun {
+		opts.Preview = &sites.Preview{}
+	}
+	if err := site.Resend(ctx, url, opts); err != nil {
+		return err
+	}
+	if *dryRun {
+		return writePreview(opts.Preview, "-")
+	}
+	slog.Info("đã gửi lại", logging.KeyURL, url)
+	return nil
+}
+
+func runReminders(ctx context.Context, args []string) error {
+	if len(args) != 2 || (args[0] != "mute" && args[0] != "unmute") {
+		return usagef("cần mute hoặc unmute và url của tin")
+	}
+	muted, url := args[0] == "mute", args[1]
+	if err := initDB(); err != nil {
+		return err
+	}
+	if err := config.MuteReminders(url, muted); err != nil {
+		return err
+	}
+	if muted {
+		slog.Info("đã tắt nhắc hạn", logging.KeyURL, url)
+	} else {
+		slog.Info("đã bật nhắc hạn", logging.KeyURL, url)
+	}
+	return nil
+}
+
+func runDocuments(ctx context.Context, args []string) error {
+	if len(args) != 1 || args[0] != "sync" {
+		return usagef("lệnh con không hợp lệ")
+	}
+	return documents.Sync(ctx)
+}
+
+func runAuth(ctx context.Context, args []string) error {
+	if len(args) != 1 || args[0] != "token" {
+		return usagef("lệnh con không hợp lệ")
+	}
+	return documents.Token(ctx)
+}
+
+func runMigrate(ctx context.Context, args []string) error {
+	if err := parseFlags(newFlagSet("migrate"), args); err != nil {
+		return err
+	}
+	if err := initDB(); err != nil {
+		return err
+	}
+	return config.Migrate()
+}
+
+// writeCalendar ghi file lịch tổng hợp nếu có đặt ICS_FEED_PATH.
+func writeCalendar() error {
+	path := os.Getenv("ICS_FEED_PATH")
+	if path == "" {
+		return nil
+	}
+	if err := ics.WriteFeed(path, calendarSince()); err != nil {
+		return fmt.Errorf("lỗi ghi lịch %s: %w", path, err)
+	}
+	return nil
+}
+
+func calendarSince() time.Time {
+	return time.Now().AddDate(0, 0, -config.GetEnvInt("ICS_FEED_DAYS", 180))
+}
+
+// writeFeeds ghi các feed Atom/RSS vào FEED_DIR nếu có đặt: feed tổng hợp, mỗi site một feed
+// và mỗi từ khóa trong FEED_KEYWORDS một feed.
+func writeFeeds() error {
+	dir := config.GetEnv("FEED_DIR", "")
+	if dir == "" {
+		return nil
+	}
+	var names []string
+	for _, s := range sites.All() {
+		names = append(names, s.Name)
+	}
+	var keywords []string
+	for _, k := range strings.Split(config.GetEnv("FEED_KEYWORDS", ""), ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keywords = append(keywords, k)
+		}
+	}
+	baseURL := strings.TrimSuffix(config.GetEnv("FEED_BASE_URL", ""), "/")
+	if err := feed.WriteFiles(dir, baseURL, feedSince(), names, keywords); err != nil {
+		return fmt.Errorf("lỗi ghi feed %s: %w", dir, err)
+	}
+	return nil
+}
+
+func feedSince() time.Time {
+	return time.Now().AddDate(0, 0, -config.GetEnvInt("FEED_DAYS", 30))
+}
+
+func runSeed(ctx context.Context, args []string) error {
+	fs := newFlagSet("seed")
+	siteNames := fs.String("site", "", "tên site cần seed, phân tách bằng dấu phẩy (bắt buộc)")
+	pages := fs.Int("pages", 3, "số trang danh sách cần crawl")
+	storeContent := fs.Bool("store-content", false, "tải trang chi tiết và lưu nội dung tin")
+	dryRun := fs.Bool("dry-run", false, "chỉ đếm, không ghi database")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *siteNames == "" {
+		return usagef("cần chỉ định --site")
+	}
+	if *pages < 1 {
+		return usagef("--pages phải lớn hơn 0")
+	}
+	targets, err := selectSites(*siteNames)
+	if err != nil {
+		return err
+	}
+	if err := initDB(); err != nil {
+		return err
+	}
+
+	var failed []error
+	for _, site := range targets {
+		res, err := site.Seed(ctx, *pages, *storeContent, sites.Options{DryRun: *dryRun})
+		slog.Info("seed xong", logging.KeySite, site.Name, logging.KeyStage, "seed", "result", res.String())
+		if err != nil {
+			slog.Error("seed lỗi", logging.KeySite, site.Name, logging.KeyStage, "seed", "error", err)
+			failed = append(failed, err)
+		}
+	}
+	return errors.Join(failed...)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"webcrawler/config"
+	"webcrawler/extract"
+)
+
+// runExport xuất các tin đã lưu cùng thông tin tuyển dụng ra CSV hoặc JSON.
+func runExport(ctx context.Context, args []string) error {
+	fs := newFlagSet("export")
+	format := fs.String("format", "csv", "định dạng xuất: csv hoặc json")
+	days := fs.Int("days", 30, "số ngày gần nhất cần xuất")
+	output := fs.String("o", "", "file xuất, mặc định in ra stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "json" {
+		return usagef("định dạng không hỗ trợ: %s", *format)
+	}
+	if err := initDB(); err != nil {
+		return err
+	}
+
+	articles, err := config.ListArticles(time.Now().AddDate(0, 0, -*days))
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("không tạo được file %s: %w", *output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "json" {
+		err = writeJSON(w, articles)
+	} else {
+		err = writeCSV(w, articles)
+	}
+	if err != nil {
+		return fmt.Errorf("lỗi khi xuất: %w", err)
+	}
+	return nil
+}
+
+type exportArticle struct {
+	URL         string              `json:"url"`
+	Site        string              `json:"site"`
+	Title       string              `json:"title"`
+	PublishedAt *time.Time          `json:"published_at,omitempty"`
+	SentAt      time.Time           `json:"sent_at"`
+	Recruitment extract.Recruitment `json:"recruitment"`
+}
+
+func writeJSON(w io.Writer, articles []config.Article) error {
+	out := make([]exportArticle, 0, len(articles))
+	for _, a := range articles {
+		e := exportArticle{URL: a.URL, Site: a.Site, Title: a.Title, SentAt: a.CreatedAt, Recruitment: a.Recruitment}
+		if !a.PublishedAt.IsZero() {
+			e.PublishedAt = &a.PublishedAt
+		}
+		out = append(out, e)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func writeCSV(w io.Writer, articles []config.Article) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"url", "site", "title", "published_at", "organization", "positions", "job_titles", "deadline", "exam_dates", "contact"})
+	for _, a := range articles {
+		r := a.Recruitment
+		row := []string{a.URL, a.Site, a.Title, formatDate(a.PublishedAt), r.Organization, "", strings.Join(r.JobTitles, "; "), "", extract.FormatDates(r.ExamDates), r.Contact}
+		if r.Positions > 0 {
+			row[5] = strconv.Itoa(r.Positions)
+		}
+		if r.Deadline != nil {
+			row[7] = formatDate(*r.Deadline)
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("02/01/2006")
+}
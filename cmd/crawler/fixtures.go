@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"webcrawler/logging"
+	"webcrawler/sites"
+)
+
+// runFixtures tải lại trang mẫu từ site thật vào thư mục testdata dùng cho test bóc tách.
+func runFixtures(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "refresh" {
+		return usagef("lệnh con không hợp lệ")
+	}
+	fs := newFlagSet("fixtures refresh")
+	siteNames := fs.String("site", "", "tên site cần tải lại, phân tách bằng dấu phẩy (mặc định tất cả)")
+	dir := fs.String("dir", "sites/testdata", "thư mục testdata")
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+	// site đang tắt vẫn cần có trang mẫu
+	targets := sites.All()
+	if *siteNames != "" {
+		var err error
+		if targets, err = selectSites(*siteNames); err != nil {
+			return err
+		}
+	}
+
+	var failed []error
+	for _, site := range targets {
+		if err := site.RefreshFixtures(ctx, *dir); err != nil {
+			slog.Error("không tải được trang mẫu", logging.KeySite, site.Name, "error", err)
+			failed = append(failed, err)
+			continue
+		}
+		slog.Info("đã tải lại trang mẫu", logging.KeySite, site.Name, "dir", *dir)
+	}
+	if len(failed) == 0 {
+		slog.Info("chạy go test ./sites -update để cập nhật kết quả mong đợi")
+	}
+	return errors.Join(failed...)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+	"webcrawler/config"
+)
+
+// runHistory in các lần chạy gần nhất, hoặc thống kê lỗi theo site với --failures.
+func runHistory(ctx context.Context, args []string) error {
+	fs := newFlagSet("history")
+	limit := fs.Int("limit", 10, "số lần chạy gần nhất cần in")
+	site := fs.String("site", "", "chỉ in kết quả của site này")
+	failures := fs.Bool("failures", false, "thống kê lỗi theo site thay vì in từng lần chạy")
+	days := fs.Int("days", 7, "số ngày gần nhất dùng cho --failures")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *limit < 1 || *days < 1 {
+		return usagef("--limit và --days phải lớn hơn 0")
+	}
+	if err := initDB(); err != nil {
+		return err
+	}
+	if *failures {
+		return printFailures(time.Now().AddDate(0, 0, -*days))
+	}
+
+	runs, err := config.RecentRuns(*limit, *site)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, r := range runs {
+		fmt.Fprintf(w, "#%d\t%s\t%s\t%s\n", r.ID, r.StartedAt.Format("02/01/2006 15:04:05"), runDuration(r.StartedAt, r.FinishedAt), r.Status)
+		for _, s := range r.Sites {
+			fmt.Fprintf(w, "  %s\t%d trang\t%d tin, %d mới\t%d đã gửi, %d lỗi\t%s\t%s\n",
+				s.Site, s.Pages, s.Items, s.New, s.Sent, s.Failed, runDuration(s.StartedAt, s.FinishedAt), s.Error)
+		}
+	}
+	return w.Flush()
+}
+
+func printFailures(since time.Time) error {
+	summaries, err := config.FailureSummaries(since)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SITE\tLẦN CHẠY\tLẦN LỖI\tTIN LỖI\tLỖI GẦN NHẤT\tLỖI")
+	for _, f := range summaries {
+		last := "-"
+		if !f.LastFailedAt.IsZero() {
+			last = f.LastFailedAt.Format("02/01/2006 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\n", f.Site, f.Runs, f.FailedRuns, f.FailedItems, last, f.LastError)
+	}
+	return w.Flush()
+}
+
+// runDuration trả về thời gian chạy làm tròn đến giây, "-" nếu chưa kết thúc.
+func runDuration(started, finished time.Time) string {
+	if finished.IsZero() {
+		return "-"
+	}
+	return finished.Sub(started).Round(time.Second).String()
+}
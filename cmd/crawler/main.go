@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	"webcrawler/cassette"
+	"webcrawler/config"
+	"webcrawler/logging"
+	"webcrawler/sites"
+
+	"github.com/joho/godotenv"
+)
+
+// mã thoát của chương trình
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+	// bị dừng bởi SIGINT/SIGTERM, kể cả khi hết thời gian chờ
+	exitInterrupted = 3
+)
+
+// usageError là lỗi do gọi sai lệnh hoặc tham số, thoát với mã exitUsage.
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...any) error {
+	return usageError{fmt.Sprintf(format, args...)}
+}
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"crawl", "[--site X[,Y]]", "crawl các site (mặc định tất cả site đang bật)", runCrawl},
+		{"seed", "--site X [--pages N] [--store-content]", "ghi các tin hiện có là đã gửi, không gửi email", runSeed},
+		{"list-sites", "", "liệt kê các site và cấu hình", runListSites},
+		{"serve", "", "chạy liên tục theo lịch cron của từng site", runServe},
+		{"resend", "<url>", "tải lại và gửi lại email cho một tin", runResend},
+		{"export", "[--format csv|json] [--days N] [-o file]", "xuất các tin đã lưu cùng thông tin tuyển dụng", runExport},
+		{"reminders", "mute|unmute <url>", "tắt/bật nhắc hạn nộp hồ sơ cho một tin", runReminders},
+		{"documents", "sync", "tải tài liệu theo Google Sheets lên Google Drive", runDocuments},
+		{"auth", "token", "lấy token Google OAuth lưu vào keys/token.json", runAuth},
+		{"history", "[--limit N] [--site X] [--failures [--days N]]", "xem lịch sử các lần chạy và thống kê lỗi", runHistory},
+		{"fixtures", "refresh [--site X] [--dir sites/testdata]", "tải lại trang mẫu dùng cho test từ site thật", runFixtures},
+		{"migrate", "", "tạo/cập nhật bảng trong database", runMigrate},
+	}
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	done := make(chan int, 1)
+	go func() { done <- run(ctx, os.Args[1:]) }()
+
+	select {
+	case code := <-done:
+		os.Exit(code)
+	case s := <-sig:
+		// không nhận việc mới, các email đang gửi được chạy nốt và ghi vào sent_links
+		grace := config.GetEnvDuration("SHUTDOWN_GRACE", 25*time.Second)
+		slog.Warn("nhận tín hiệu dừng, chờ các việc đang chạy", "signal", s.String(), "grace", grace)
+		cancel()
+		select {
+		case code := <-done:
+			os.Exit(code)
+		case s := <-sig:
+			slog.Error("nhận tín hiệu dừng lần hai, thoát ngay", "signal", s.String())
+		case <-time.After(grace):
+			slog.Error("hết thời gian chờ, thoát khi còn việc đang chạy", "grace", grace)
+		}
+		os.Exit(exitInterrupted)
+	}
+}
+
+func run(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage()
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Lệnh không hợp lệ: %s\n\n", args[0])
+		printUsage()
+		return exitUsage
+	}
+
+	envErr := godotenv.Load()
+	if err := logging.Setup(os.Stderr, config.GetEnv("LOG_FORMAT", "text"), config.GetEnv("LOG_LEVEL", "info")); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if envErr != nil {
+		slog.Info("không tìm thấy file .env, dùng env của OS")
+	}
+	if err := setupCassette(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	err := cmd.run(ctx, args[1:])
+	var ue usageError
+	switch {
+	case ctx.Err() != nil:
+		slog.Info("đã dừng theo tín hiệu", "command", cmd.name, "error", err)
+		return exitInterrupted
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &ue):
+		fmt.Fprintf(os.Stderr, "%v\nCách dùng: crawler %s %s\n", err, cmd.name, cmd.args)
+		return exitUsage
+	default:
+		slog.Error("lệnh lỗi", "command", cmd.name, "error", err)
+		return exitFailure
+	}
+}
+
+// setupCassette bật ghi/phát lại phản hồi HTTP theo CASSETTE_MODE (off|record|replay|cache)
+// với thư mục CASSETTE_DIR, dùng khi sửa selector mà không gọi tới site thật.
+// Chế độ cache giữ phản hồi trong CASSETTE_MAX_AGE (mặc định 1h) rồi mới tải lại.
+func setupCassette() error {
+	mode, err := cassette.ParseMode(config.GetEnv("CASSETTE_MODE", ""))
+	if err != nil || mode == cassette.Off {
+		return err
+	}
+	dir := config.GetEnv("CASSETTE_DIR", "cassettes")
+	maxAge := config.GetEnvDuration("CASSETTE_MAX_AGE", time.Hour)
+	sites.WrapTransport(func(next http.RoundTripper) http.RoundTripper {
+		t := cassette.New(dir, mode, next)
+		t.MaxAge = maxAge
+		return t
+	})
+	slog.Info("đang dùng cassette", "mode", mode, "dir", dir)
+	return nil
+}
+
+// initDB kết nối database, gọi sau khi đã kiểm tra tham số của lệnh.
+func initDB() error {
+	if err := config.InitDB(); err != nil {
+		return fmt.Errorf("lỗi khởi tạo DB: %w", err)
+	}
+	return nil
+}
+
+// migrateDB áp dụng các migration chưa chạy khi khởi động serve và crawl, để bản mới không chạy
+// trên bảng cũ. AUTO_MIGRATE=false thì chỉ chạy bằng lệnh migrate.
+func migrateDB() error {
+	if !config.GetEnvBool("AUTO_MIGRATE", true) {
+		return nil
+	}
+	if err := config.Migrate(); err != nil {
+		return fmt.Errorf("lỗi migrate database: %w", err)
+	}
+	return nil
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Cách dùng: crawler <lệnh> [tham số]")
+	fmt.Fprintln(os.Stderr, "\nCác lệnh:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %-40s %s\n", c.name, c.args, c.summary)
+	}
+}
+
+// newFlagSet tạo FlagSet trả lỗi thay vì thoát chương trình.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// parseFlags parse tham số, lỗi parse được coi là lỗi cách dùng.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{err.Error()}
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"webcrawler/config"
+	"webcrawler/documents"
+	"webcrawler/feed"
+	"webcrawler/healthcheck"
+	"webcrawler/helpers"
+	"webcrawler/ics"
+	"webcrawler/metrics"
+	"webcrawler/scheduler"
+	"webcrawler/sites"
+)
+
+// runServe chạy liên tục, các site theo lịch cron riêng (site cùng lịch chạy chung), và mở HTTP để xem trạng thái, lịch .ics, feed.
+func runServe(ctx context.Context, args []string) error {
+	if err := parseFlags(newFlagSet("serve"), args); err != nil {
+		return err
+	}
+	if err := initDB(); err != nil {
+		return err
+	}
+	if err := migrateDB(); err != nil {
+		return err
+	}
+
+	// các site chạy lệch giờ nhau nên không có tín hiệu start, mỗi job ping kết quả. Check dùng chung
+	// báo lỗi khi còn site nào lỗi ở lần chạy gần nhất, để site chạy tốt không che site đang lỗi
+	check := healthcheck.Check(config.GetEnv("HEALTHCHECK_URL", ""))
+	health := &sites.Health{}
+	loc := helpers.Location
+	if tz := config.GetEnv("CRON_TZ", ""); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("CRON_TZ %q không hợp lệ: %w", tz, err)
+		}
+	}
+	sched := scheduler.New(config.GetEnvDuration("SCHEDULE_JITTER", 2*time.Minute), loc)
+	var enabled []*sites.Site
+	for _, site := range sites.All() {
+		if !site.Disabled {
+			enabled = append(enabled, site)
+		}
+	}
+	// các site cùng lịch chạy chung một job để tin trùng giữa các site được gộp vào một email,
+	// job mang tên các site nối bằng "+", POST /run/<site> chạy job chứa site đó
+	jobOf := map[string]string{}
+	for _, group := range sites.BySchedule(enabled) {
+		names := make([]string, len(group))
+		for i, site := range group {
+			names[i] = site.Name
+		}
+		name := strings.Join(names, "+")
+		for _, n := range names {
+			jobOf[n] = name
+		}
+		crawl := func(ctx context.Context) error {
+			results, err := sites.Run(ctx, group, sites.Options{})
+			check.Finish(ctx, health.Update(results), sites.Summary(results))
+			// lỗi ghi feed không tính là lỗi crawl site
+			if ferr := writeFeeds(); ferr != nil {
+				slog.Warn("không ghi được feed", "error", ferr)
+			}
+			return err
+		}
+		if err := sched.Add(name, group[0].Schedule, crawl); err != nil {
+			return err
+		}
+	}
+	err := sched.Add("reminders", config.GetEnv("REMINDERS_SCHEDULE", "30 * * * *"), func(ctx context.Context) error {
+		if err := sites.SendDueReminders(ctx); err != nil {
+			return err
+		}
+		return writeCalendar()
+	})
+	if err != nil {
+		return err
+	}
+	// job tải tài liệu chỉ chạy khi có đặt lịch
+	if spec := config.GetEnv("DOCUMENTS_SCHEDULE", ""); spec != "" {
+		if err := sched.Add("documents", spec, documents.Sync); err != nil {
+			return err
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sched.Status())
+	})
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("POST /run/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if ctx.Err() != nil {
+			http.Error(w, "đang dừng", http.StatusServiceUnavailable)
+			return
+		}
+		name := r.PathValue("name")
+		if job, ok := jobOf[name]; ok {
+			name = job
+		}
+		if !sched.RunNow(ctx, name) {
+			http.Error(w, "job không tồn tại hoặc đang chạy", http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("GET /calendar.ics", func(w http.ResponseWriter, r *http.Request) {
+		data, err := ics.FeedCalendar(calendarSince())
+		if err != nil {
+			slog.Error("lỗi tạo lịch", "error", err)
+			http.Error(w, "lỗi tạo lịch", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Write(data)
+	})
+	mux.HandleFunc("GET /feed.atom", serveFeed(feed.Atom))
+	mux.HandleFunc("GET /feed.rss", serveFeed(feed.RSS))
+
+	addr := config.GetEnv("HTTP_ADDR", ":8080")
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		slog.Info("HTTP đang lắng nghe", "addr", addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("lỗi HTTP server", "error", err)
+		}
+	}()
+	defer srv.Close()
+	// khi dừng thì ngừng nhận yêu cầu chạy job mới, scheduler chờ các job đang chạy
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	for _, st := range sched.Status() {
+		slog.Info("đã lên lịch", "job", st.Name, "spec", st.Spec)
+	}
+	sched.Run(ctx)
+	return fmt.Errorf("scheduler dừng: %w", ctx.Err())
+}
+
+// serveFeed phục vụ feed các tin đã lưu theo định dạng format, lọc theo ?site= và ?keyword=.
+func serveFeed(format feed.Format) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scheme := "http"
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		self := scheme + "://" + r.Host + r.URL.RequestURI()
+		f := feed.Filter{Site: r.URL.Query().Get("site"), Keyword: r.URL.Query().Get("keyword")}
+		data, err := feed.Build(feedSince(), f, format, self)
+		if err != nil {
+			slog.Error("lỗi tạo feed", "error", err)
+			http.Error(w, "lỗi tạo feed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Write(data)
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+	"webcrawler/extract"
+	"webcrawler/logging"
+)
+
+// Article là tin đã gửi được lưu lại cùng thông tin tuyển dụng trích được.
+type Article struct {
+	URL         string
+	Site        string
+	Title       string
+	PublishedAt time.Time
+	Content     string
+	Recruitment extract.Recruitment
+	// ContentHash là hash nội dung và tệp đính kèm, dùng để phát hiện tin bị sửa sau khi gửi
+	ContentHash string
+	// Seeded là tin được lưu bởi seed --store-content, chưa từng gửi email nên không kiểm tra lại
+	Seeded    bool
+	CreatedAt time.Time
+}
+
+// SaveArticle lưu (hoặc cập nhật) tin theo url.
+func SaveArticle(a Article) {
+	rec, err := json.Marshal(a.Recruitment)
+	if err != nil {
+		slog.Error("lỗi mã hóa thông tin tuyển dụng", logging.KeyURL, a.URL, "error", err)
+		return
+	}
+	_, err = DB.Exec(`INSERT INTO articles(url, site, title, published_at, content, recruitment, content_hash, seeded, checked_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+		ON DUPLICATE KEY UPDATE site = VALUES(site), title = VALUES(title), published_at = VALUES(published_at),
+			content = VALUES(content), recruitment = VALUES(recruitment), content_hash = VALUES(content_hash),
+			seeded = VALUES(seeded), checked_at = NOW()`,
+		a.URL, a.Site, a.Title, nullTime(a.PublishedAt), a.Content, string(rec), nullString(a.ContentHash), a.Seeded)
+	if err != nil {
+		slog.Error("lỗi lưu tin", logging.KeyURL, a.URL, "error", dbError("save_article", err))
+	}
+}
+
+// ListArticles trả về các tin được lưu từ thời điểm since, mới nhất trước.
+func ListArticles(since time.Time) ([]Article, error) {
+	rows, err := DB.Query(`SELECT url, site, title, published_at, content, recruitment, content_hash, created_at
+		FROM articles WHERE created_at >= ? ORDER BY created_at DESC`, since)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi đọc danh sách tin: %w", err)
+	}
+	defer rows.Close()
+
+	var articles []Article
+	for rows.Next() {
+		a, err := scanArticle(rows)
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, a)
+	}
+	return articles, rows.Err()
+}
+
+// ArticlesToRecheck trả về các tin đã gửi của site được lưu từ since và chưa kiểm tra lại từ checkedBefore,
+// kiểm tra lâu nhất trước. Tin chỉ được seed lưu lại không nằm trong danh sách.
+func ArticlesToRecheck(site string, since, checkedBefore time.Time) ([]Article, error) {
+	rows, err := DB.Query(`SELECT url, site, title, published_at, content, recruitment, content_hash, created_at
+		FROM articles WHERE site = ? AND NOT seeded AND created_at >= ? AND (checked_at IS NULL OR checked_at < ?)
+		ORDER BY checked_at, created_at`, site, since, checkedBefore)
+	if err != nil {
+		return nil, dbError("recheck_articles", fmt.Errorf("lỗi đọc tin cần kiểm tra lại: %w", err))
+	}
+	defer rows.Close()
+
+	var articles []Article
+	for rows.Next() {
+		a, err := scanArticle(rows)
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, a)
+	}
+	return articles, rows.Err()
+}
+
+// MarkArticleChecked ghi nhận tin url vừa được kiểm tra lại và không đổi.
+func MarkArticleChecked(url, contentHash string) error {
+	_, err := DB.Exec("UPDATE articles SET checked_at = NOW(), content_hash = ? WHERE url = ?", contentHash, url)
+	if err != nil {
+		return dbError("recheck_articles", fmt.Errorf("lỗi cập nhật tin %s: %w", url, err))
+	}
+	return nil
+}
+
+// GetArticle trả về tin đã lưu theo url.
+func GetArticle(url string) (Article, error) {
+	row := DB.QueryRow(`SELECT url, site, title, published_at, content, recruitment, content_hash, created_at
+		FROM articles WHERE url = ?`, url)
+	return scanArticle(row)
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanArticle(row scanner) (Article, error) {
+	var (
+		a         Article
+		published sql.NullTime
+		rec       sql.NullString
+		hash      sql.NullString
+	)
+	if err := row.Scan(&a.URL, &a.Site, &a.Title, &published, &a.Content, &rec, &hash, &a.CreatedAt); err != nil {
+		return a, fmt.Errorf("lỗi đọc tin: %w", err)
+	}
+	a.PublishedAt, a.ContentHash = published.Time, hash.String
+	if rec.Valid && rec.String != "" {
+		if err := json.Unmarshal([]byte(rec.String), &a.Recruitment); err != nil {
+			return a, fmt.Errorf("lỗi đọc thông tin tuyển dụng %s: %w", a.URL, err)
+		}
+	}
+	return a, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -3,8 +3,10 @@ package config
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"webcrawler/logging"
+	"webcrawler/metrics"
 
 	_ "github.com/go-sql-driver/mysql"
 )
@@ -22,25 +24,30 @@ func InitDB() error {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?tls=%s&charset=utf8mb4&parseTime=True",
 		user, pass, host, port, name, tls)
 
-	var err error
-	DB, err = sql.Open("mysql", dsn)
+	db, err := sql.Open("mysql", dsn)
 	if err != nil {
 		return fmt.Errorf("lỗi kết nối DB: %w", err)
 	}
 
-	if err := DB.Ping(); err != nil {
+	if err := db.Ping(); err != nil {
+		db.Close()
 		return fmt.Errorf("ping thất bại: %w", err)
 	}
+	DB = db
 
-	log.Println("✅ Đã kết nối database TiDB")
+	slog.Info("đã kết nối database", "host", host, "db", name)
 	return nil
 }
 
 func IsLinkSent(url string) bool {
+	if DB == nil {
+		// chạy thử không có database: coi như chưa gửi
+		return false
+	}
 	var count int
 	err := DB.QueryRow("SELECT COUNT(1) FROM sent_links WHERE url = ?", url).Scan(&count)
 	if err != nil {
-		log.Println("Lỗi kiểm tra link:", err)
+		slog.Error("lỗi kiểm tra link", logging.KeyURL, url, "error", dbError("is_link_sent", err))
 		return false
 	}
 	return count > 0
@@ -49,7 +56,22 @@ func IsLinkSent(url string) bool {
 func MarkLinkAsSent(url string) {
 	_, err := DB.Exec("INSERT IGNORE INTO sent_links(url, sent_at) VALUES (?, NOW())", url)
 	if err != nil {
-		log.Println("Lỗi ghi link đã gửi:", err)
+		slog.Error("lỗi ghi link đã gửi", logging.KeyURL, url, "error", dbError("mark_link_sent", err))
+	}
+	slog.Info("đã gửi mail thành công", logging.KeyStage, "deliver", logging.KeyURL, url)
+}
+
+// MarkLinkAsSeeded ghi nhận link như đã gửi mà không gửi email (chế độ seed).
+func MarkLinkAsSeeded(url string) error {
+	_, err := DB.Exec("INSERT IGNORE INTO sent_links(url, sent_at) VALUES (?, NOW())", url)
+	if err != nil {
+		return dbError("mark_link_seeded", fmt.Errorf("lỗi ghi link: %w", err))
 	}
-	log.Println("Đã gửi mail thành công: ", url)
+	return nil
+}
+
+// dbError đếm lỗi database theo thao tác op để theo dõi, rồi trả lại err.
+func dbError(op string, err error) error {
+	metrics.DBErrors.Inc(op)
+	return err
 }
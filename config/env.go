@@ -0,0 +1,78 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetEnv trả về giá trị biến môi trường key, hoặc def nếu không được đặt.
+func GetEnv(key string, def string) string {
+	if v, ok := os.LookupEnv(key); ok && strings.TrimSpace(v) != "" {
+		return strings.TrimSpace(v)
+	}
+	return def
+}
+
+// GetEnvInt đọc biến môi trường kiểu số nguyên, sai định dạng thì dùng def.
+func GetEnvInt(key string, def int) int {
+	v := GetEnv(key, "")
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		slog.Warn("biến môi trường không phải số, dùng mặc định", "key", key, "value", v, "default", def)
+		return def
+	}
+	return n
+}
+
+// GetEnvInts đọc danh sách số nguyên phân tách bằng dấu phẩy, ví dụ "7,1".
+func GetEnvInts(key string, def []int) []int {
+	v := GetEnv(key, "")
+	if v == "" {
+		return def
+	}
+	var out []int
+	for _, part := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			slog.Warn("biến môi trường không hợp lệ, dùng mặc định", "key", key, "value", v, "default", def)
+			return def
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// GetEnvDuration đọc biến môi trường dạng time.Duration, ví dụ "2m", "30s".
+func GetEnvDuration(key string, def time.Duration) time.Duration {
+	v := GetEnv(key, "")
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		slog.Warn("biến môi trường không hợp lệ, dùng mặc định", "key", key, "value", v, "default", def)
+		return def
+	}
+	return d
+}
+
+// GetEnvBool đọc biến môi trường dạng bool (true/false, 1/0, yes/no).
+func GetEnvBool(key string, def bool) bool {
+	switch strings.ToLower(GetEnv(key, "")) {
+	case "":
+		return def
+	case "1", "true", "yes", "on":
+		return true
+	case "0", "false", "no", "off":
+		return false
+	default:
+		slog.Warn("biến môi trường không phải true/false, dùng mặc định", "key", key, "default", def)
+		return def
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import "fmt"
+
+// SiteHealth là số lần chạy lỗi selector liên tiếp của một site.
+type SiteHealth struct {
+	Site           string
+	EmptyRuns      int
+	DetailMissRuns int
+}
+
+// UpdateSiteHealth tăng hoặc đặt lại bộ đếm lỗi selector của site sau một lần chạy.
+// detailChecked là false khi lần chạy không tải trang chi tiết nào, bộ đếm chi tiết giữ nguyên.
+func UpdateSiteHealth(site string, listEmpty, detailChecked, detailBroken bool) (SiteHealth, error) {
+	h := SiteHealth{Site: site}
+	_, err := DB.Exec(`INSERT INTO site_health(site, empty_runs, detail_miss_runs, updated_at)
+		VALUES (?, ?, ?, NOW())
+		ON DUPLICATE KEY UPDATE
+			empty_runs = IF(?, empty_runs + 1, 0),
+			detail_miss_runs = IF(?, IF(?, detail_miss_runs + 1, 0), detail_miss_runs),
+			updated_at = NOW()`,
+		site, boolToInt(listEmpty), boolToInt(detailChecked && detailBroken),
+		listEmpty, detailChecked, detailBroken)
+	if err != nil {
+		return h, dbError("site_health", fmt.Errorf("lỗi cập nhật site_health: %w", err))
+	}
+	err = DB.QueryRow("SELECT empty_runs, detail_miss_runs FROM site_health WHERE site = ?", site).
+		Scan(&h.EmptyRuns, &h.DetailMissRuns)
+	if err != nil {
+		return h, dbError("site_health", fmt.Errorf("lỗi đọc site_health: %w", err))
+	}
+	return h, nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -1,19 +1,58 @@
 package config
 
 import (
+	"bytes"
 	"net/smtp"
 	"os"
+	"strings"
+	"webcrawler/metrics"
 
 	"github.com/jordan-wright/email"
 )
 
-func SendEmail(subject string, htmlContent string) error {
+// Recipients trả về danh sách người nhận mặc định (EMAIL_TO, phân tách bằng dấu phẩy).
+func Recipients() []string {
+	var to []string
+	for _, addr := range strings.Split(os.Getenv("EMAIL_TO"), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	return to
+}
+
+// Attachment là file đính kèm email.
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+func SendEmail(subject string, htmlContent string, attachments ...Attachment) error {
+	return SendEmailTo(Recipients(), subject, htmlContent, attachments...)
+}
+
+// SendEmailTo gửi email HTML tới danh sách người nhận to.
+func SendEmailTo(to []string, subject string, htmlContent string, attachments ...Attachment) error {
+	err := sendEmail(to, subject, htmlContent, attachments)
+	if err != nil {
+		metrics.NotificationFailures.Inc("email")
+	}
+	return err
+}
+
+func sendEmail(to []string, subject string, htmlContent string, attachments []Attachment) error {
 	e := email.NewEmail()
 	e.From = os.Getenv("SMTP_FROM")
-	e.To = []string{os.Getenv("EMAIL_TO")}
+	e.To = to
 	e.Cc = []string{os.Getenv("EMAIL_CC")} // always cc to me
 	e.Subject = subject
 	e.HTML = []byte(htmlContent)
+	for _, a := range attachments {
+		if _, err := e.Attach(bytes.NewReader(a.Data), a.Name, a.ContentType); err != nil {
+			return err
+		}
+	}
 
 	smtpServer := os.Getenv("SMTP_SERVER")
 	smtpPort := os.Getenv("SMTP_PORT")
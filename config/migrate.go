@@ -0,0 +1,73 @@
+package config
+
+import (
+	"embed"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrate áp dụng các file migrations/*.sql chưa chạy theo thứ tự tên file.
+// Mỗi file chỉ chạy một lần, được ghi lại trong bảng schema_migrations.
+func Migrate() error {
+	_, err := DB.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version VARCHAR(255) PRIMARY KEY,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("lỗi tạo bảng schema_migrations: %w", err)
+	}
+
+	applied := map[string]bool{}
+	rows, err := DB.Query("SELECT version FROM schema_migrations")
+	if err != nil {
+		return fmt.Errorf("lỗi đọc schema_migrations: %w", err)
+	}
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return fmt.Errorf("lỗi đọc schema_migrations: %w", err)
+		}
+		applied[v] = true
+	}
+	rows.Close()
+
+	entries, err := migrations.ReadDir("migrations")
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := strings.TrimSuffix(name, ".sql")
+		if applied[version] {
+			continue
+		}
+		data, err := migrations.ReadFile("migrations/" + name)
+		if err != nil {
+			return err
+		}
+		for _, stmt := range strings.Split(string(data), ";") {
+			if strings.TrimSpace(stmt) == "" {
+				continue
+			}
+			if _, err := DB.Exec(stmt); err != nil {
+				return fmt.Errorf("migration %s: %w", name, err)
+			}
+		}
+		if _, err := DB.Exec("INSERT INTO schema_migrations(version) VALUES (?)", version); err != nil {
+			return fmt.Errorf("lỗi ghi schema_migrations: %w", err)
+		}
+		slog.Info("đã chạy migration", "version", version)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS sent_links (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    url VARCHAR(255),
+    sent_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS articles (
+    url VARCHAR(255) PRIMARY KEY,
+    site VARCHAR(50) NOT NULL,
+    title VARCHAR(512) NOT NULL DEFAULT '',
+    published_at DATETIME NULL,
+    content MEDIUMTEXT,
+    recruitment JSON,
+    reminders_muted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_articles_created_at (created_at)
+);
+
+CREATE TABLE IF NOT EXISTS reminders (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    url VARCHAR(255) NOT NULL,
+    days_before INT NOT NULL,
+    remind_at DATETIME NOT NULL,
+    recipients TEXT,
+    sent_at DATETIME NULL,
+    UNIQUE KEY uniq_reminders_url_days (url, days_before),
+    INDEX idx_reminders_remind_at (remind_at)
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS site_health (
+    site VARCHAR(50) PRIMARY KEY,
+    empty_runs INT NOT NULL DEFAULT 0,
+    detail_miss_runs INT NOT NULL DEFAULT 0,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS crawl_runs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME NULL,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    error TEXT,
+    INDEX idx_crawl_runs_started_at (started_at)
+);
+
+CREATE TABLE IF NOT EXISTS crawl_site_runs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    run_id BIGINT NOT NULL,
+    site VARCHAR(50) NOT NULL,
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME NOT NULL,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    pages_fetched INT NOT NULL DEFAULT 0,
+    items_found INT NOT NULL DEFAULT 0,
+    new_items INT NOT NULL DEFAULT 0,
+    sent INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    error TEXT,
+    INDEX idx_crawl_site_runs_run_id (run_id),
+    INDEX idx_crawl_site_runs_site_started (site, started_at)
+);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS page_cache (
+    url VARCHAR(512) PRIMARY KEY,
+    etag VARCHAR(255),
+    last_modified VARCHAR(64),
+    body_hash CHAR(64) NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
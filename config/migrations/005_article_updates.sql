@@ -0,0 +1,4 @@
+ALTER TABLE articles
+    ADD COLUMN content_hash CHAR(64) NULL,
+    ADD COLUMN checked_at DATETIME NULL,
+    ADD INDEX idx_articles_site_created_at (site, created_at);
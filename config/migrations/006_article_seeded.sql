@@ -0,0 +1,2 @@
+ALTER TABLE articles
+    ADD COLUMN seeded BOOLEAN NOT NULL DEFAULT FALSE;
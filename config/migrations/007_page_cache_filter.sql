@@ -0,0 +1,2 @@
+ALTER TABLE page_cache
+    ADD COLUMN filter_hash CHAR(64) NULL;
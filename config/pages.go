@@ -0,0 +1,47 @@
+package config
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// PageValidator là thông tin của lần tải trước để hỏi server trang đã đổi chưa
+// (If-None-Match, If-Modified-Since) và so nội dung khi server không hỗ trợ.
+type PageValidator struct {
+	URL          string
+	ETag         string
+	LastModified string
+	// BodyHash là sha256 dạng hex của nội dung trang
+	BodyHash string
+	// FilterHash là hash bộ lọc tin của site lúc lưu, bộ lọc đổi thì không dùng thông tin cũ
+	FilterHash string
+}
+
+// GetPageValidator trả về thông tin đã lưu của url, rỗng nếu chưa có.
+func GetPageValidator(url string) (PageValidator, error) {
+	v := PageValidator{URL: url}
+	var etag, lastModified, filter sql.NullString
+	err := DB.QueryRow("SELECT etag, last_modified, body_hash, filter_hash FROM page_cache WHERE url = ?", url).
+		Scan(&etag, &lastModified, &v.BodyHash, &filter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return v, nil
+	}
+	if err != nil {
+		return v, dbError("page_cache", fmt.Errorf("lỗi đọc page_cache: %w", err))
+	}
+	v.ETag, v.LastModified, v.FilterHash = etag.String, lastModified.String, filter.String
+	return v, nil
+}
+
+// SavePageValidator ghi thông tin của lần tải trang thành công.
+func SavePageValidator(v PageValidator) error {
+	_, err := DB.Exec(`INSERT INTO page_cache(url, etag, last_modified, body_hash, filter_hash) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE etag = VALUES(etag), last_modified = VALUES(last_modified), body_hash = VALUES(body_hash),
+			filter_hash = VALUES(filter_hash)`,
+		v.URL, nullString(v.ETag), nullString(v.LastModified), v.BodyHash, nullString(v.FilterHash))
+	if err != nil {
+		return dbError("page_cache", fmt.Errorf("lỗi ghi page_cache: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"webcrawler/extract"
+	"webcrawler/logging"
+)
+
+// Reminder là một lần nhắc hạn nộp hồ sơ của tin đã gửi.
+type Reminder struct {
+	ID          int64
+	URL         string
+	Title       string
+	DaysBefore  int
+	Recipients  []string
+	Recruitment extract.Recruitment
+}
+
+// ScheduleReminders tạo lịch nhắc trước hạn deadline các số ngày trong daysBefore,
+// bỏ qua các mốc đã qua. Lịch đã có của cùng url và số ngày được cập nhật theo hạn mới.
+func ScheduleReminders(url string, deadline time.Time, daysBefore []int, recipients []string, now time.Time) {
+	for _, d := range daysBefore {
+		// nhắc lúc 8h sáng của ngày cần nhắc
+		remindAt := deadline.AddDate(0, 0, -d).Add(8 * time.Hour)
+		if remindAt.Before(now) {
+			continue
+		}
+		_, err := DB.Exec(`INSERT INTO reminders(url, days_before, remind_at, recipients)
+			VALUES (?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE remind_at = VALUES(remind_at), sent_at = NULL`,
+			url, d, remindAt, strings.Join(recipients, ","))
+		if err != nil {
+			slog.Error("lỗi tạo lịch nhắc", logging.KeyURL, url, "error", dbError("schedule_reminders", err))
+		}
+	}
+}
+
+// DueReminders trả về các lịch nhắc đến hạn, chưa gửi và không bị tắt.
+func DueReminders(now time.Time) ([]Reminder, error) {
+	rows, err := DB.Query(`SELECT r.id, r.url, r.days_before, r.recipients, a.title, a.recruitment
+		FROM reminders r JOIN articles a ON a.url = r.url
+		WHERE r.sent_at IS NULL AND r.remind_at <= ? AND a.reminders_muted = FALSE
+		ORDER BY r.remind_at`, now)
+	if err != nil {
+		return nil, dbError("due_reminders", fmt.Errorf("lỗi đọc lịch nhắc: %w", err))
+	}
+	defer rows.Close()
+
+	var reminders []Reminder
+	for rows.Next() {
+		var (
+			r          Reminder
+			recipients sql.NullString
+			rec        sql.NullString
+		)
+		if err := rows.Scan(&r.ID, &r.URL, &r.DaysBefore, &recipients, &r.Title, &rec); err != nil {
+			return nil, fmt.Errorf("lỗi đọc lịch nhắc: %w", err)
+		}
+		for _, addr := range strings.Split(recipients.String, ",") {
+			if addr != "" {
+				r.Recipients = append(r.Recipients, addr)
+			}
+		}
+		if rec.Valid && rec.String != "" {
+			if err := json.Unmarshal([]byte(rec.String), &r.Recruitment); err != nil {
+				return nil, fmt.Errorf("lỗi đọc thông tin tuyển dụng %s: %w", r.URL, err)
+			}
+		}
+		reminders = append(reminders, r)
+	}
+	return reminders, rows.Err()
+}
+
+// MarkReminderSent đánh dấu lịch nhắc đã gửi.
+func MarkReminderSent(id int64) {
+	_, err := DB.Exec("UPDATE reminders SET sent_at = ? WHERE id = ?", time.Now(), id)
+	if err != nil {
+		slog.Error("lỗi ghi lịch nhắc đã gửi", "reminder_id", id, "error", dbError("mark_reminder_sent", err))
+	}
+}
+
+// MuteReminders bật/tắt nhắc hạn cho tin url.
+func MuteReminders(url string, muted bool) error {
+	res, err := DB.Exec("UPDATE articles SET reminders_muted = ? WHERE url = ?", muted, url)
+	if err != nil {
+		return fmt.Errorf("lỗi cập nhật nhắc hạn: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if err := DB.QueryRow("SELECT 1 FROM articles WHERE url = ?", url).Scan(new(int)); err == sql.ErrNoRows {
+			return fmt.Errorf("không tìm thấy tin %s", url)
+		}
+	}
+	return nil
+}
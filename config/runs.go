@@ -0,0 +1,191 @@
+package config
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SiteRun là kết quả crawl một site trong một lần chạy.
+type SiteRun struct {
+	RunID      int64
+	Site       string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Pages      int
+	Items      int
+	New        int
+	Sent       int
+	Failed     int
+	Error      string
+}
+
+// Run là một lần chạy crawl cùng kết quả từng site.
+type Run struct {
+	ID         int64
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Status     string
+	Error      string
+	Sites      []SiteRun
+}
+
+// FailureSummary là thống kê lỗi của một site trong một khoảng thời gian.
+type FailureSummary struct {
+	Site         string
+	Runs         int
+	FailedRuns   int
+	FailedItems  int
+	LastError    string
+	LastFailedAt time.Time
+}
+
+// StartRun ghi nhận bắt đầu một lần chạy và trả về id.
+func StartRun(startedAt time.Time) (int64, error) {
+	res, err := DB.Exec("INSERT INTO crawl_runs(started_at, status) VALUES (?, 'running')", startedAt)
+	if err != nil {
+		return 0, dbError("record_run", fmt.Errorf("lỗi ghi lần chạy: %w", err))
+	}
+	return res.LastInsertId()
+}
+
+// FinishRun ghi nhận kết thúc lần chạy id với trạng thái status (success|failed|interrupted).
+func FinishRun(id int64, startedAt, finishedAt time.Time, status, errText string) error {
+	_, err := DB.Exec(`UPDATE crawl_runs SET finished_at = ?, duration_ms = ?, status = ?, error = ? WHERE id = ?`,
+		finishedAt, finishedAt.Sub(startedAt).Milliseconds(), status, nullString(errText), id)
+	if err != nil {
+		return dbError("record_run", fmt.Errorf("lỗi ghi lần chạy: %w", err))
+	}
+	return nil
+}
+
+// RecordSiteRun lưu kết quả crawl một site.
+func RecordSiteRun(r SiteRun) error {
+	_, err := DB.Exec(`INSERT INTO crawl_site_runs(run_id, site, started_at, finished_at, duration_ms,
+			pages_fetched, items_found, new_items, sent, failed, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.RunID, r.Site, r.StartedAt, r.FinishedAt, r.FinishedAt.Sub(r.StartedAt).Milliseconds(),
+		r.Pages, r.Items, r.New, r.Sent, r.Failed, nullString(r.Error))
+	if err != nil {
+		return dbError("record_run", fmt.Errorf("lỗi ghi kết quả site %s: %w", r.Site, err))
+	}
+	return nil
+}
+
+// RecentRuns trả về limit lần chạy gần nhất (có site nếu site khác rỗng), mới nhất trước.
+func RecentRuns(limit int, site string) ([]Run, error) {
+	query := `SELECT id, started_at, finished_at, status, error FROM crawl_runs ORDER BY id DESC LIMIT ?`
+	args := []any{limit}
+	if site != "" {
+		query = `SELECT id, started_at, finished_at, status, error FROM crawl_runs
+			WHERE id IN (SELECT run_id FROM crawl_site_runs WHERE site = ?) ORDER BY id DESC LIMIT ?`
+		args = []any{site, limit}
+	}
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi đọc lịch sử chạy: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		var (
+			r        Run
+			finished sql.NullTime
+			errText  sql.NullString
+		)
+		if err := rows.Scan(&r.ID, &r.StartedAt, &finished, &r.Status, &errText); err != nil {
+			return nil, fmt.Errorf("lỗi đọc lịch sử chạy: %w", err)
+		}
+		r.FinishedAt, r.Error = finished.Time, errText.String
+		runs = append(runs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range runs {
+		if runs[i].Sites, err = siteRuns(runs[i].ID, site); err != nil {
+			return nil, err
+		}
+	}
+	return runs, nil
+}
+
+func siteRuns(runID int64, site string) ([]SiteRun, error) {
+	rows, err := DB.Query(`SELECT site, started_at, finished_at, pages_fetched, items_found, new_items, sent, failed, error
+		FROM crawl_site_runs WHERE run_id = ? AND (? = '' OR site = ?) ORDER BY site`, runID, site, site)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi đọc lịch sử chạy: %w", err)
+	}
+	defer rows.Close()
+
+	var out []SiteRun
+	for rows.Next() {
+		r := SiteRun{RunID: runID}
+		var errText sql.NullString
+		if err := rows.Scan(&r.Site, &r.StartedAt, &r.FinishedAt, &r.Pages, &r.Items, &r.New, &r.Sent, &r.Failed, &errText); err != nil {
+			return nil, fmt.Errorf("lỗi đọc lịch sử chạy: %w", err)
+		}
+		r.Error = errText.String
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// FailureSummaries thống kê lỗi theo site từ thời điểm since.
+func FailureSummaries(since time.Time) ([]FailureSummary, error) {
+	rows, err := DB.Query(`SELECT site, COUNT(*),
+			SUM(CASE WHEN error IS NOT NULL THEN 1 ELSE 0 END), SUM(failed),
+			MAX(CASE WHEN error IS NOT NULL OR failed > 0 THEN started_at END)
+		FROM crawl_site_runs WHERE started_at >= ? GROUP BY site ORDER BY site`, since)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi thống kê lỗi: %w", err)
+	}
+	defer rows.Close()
+
+	var out []FailureSummary
+	for rows.Next() {
+		var (
+			f          FailureSummary
+			lastFailed sql.NullTime
+		)
+		if err := rows.Scan(&f.Site, &f.Runs, &f.FailedRuns, &f.FailedItems, &lastFailed); err != nil {
+			return nil, fmt.Errorf("lỗi thống kê lỗi: %w", err)
+		}
+		f.LastFailedAt = lastFailed.Time
+		out = append(out, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range out {
+		var errText sql.NullString
+		err := DB.QueryRow(`SELECT error FROM crawl_site_runs WHERE site = ? AND error IS NOT NULL AND started_at >= ?
+			ORDER BY started_at DESC LIMIT 1`, out[i].Site, since).Scan(&errText)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("lỗi thống kê lỗi: %w", err)
+		}
+		out[i].LastError = errText.String
+	}
+	return out, nil
+}
+
+// LastSiteSuccess trả về thời điểm kết thúc lần crawl không lỗi gần nhất của site,
+// zero nếu chưa có.
+func LastSiteSuccess(site string) (time.Time, error) {
+	if DB == nil {
+		return time.Time{}, nil
+	}
+	var last sql.NullTime
+	err := DB.QueryRow("SELECT MAX(finished_at) FROM crawl_site_runs WHERE site = ? AND error IS NULL", site).Scan(&last)
+	if err != nil {
+		return time.Time{}, dbError("last_site_success", fmt.Errorf("lỗi đọc lịch sử chạy: %w", err))
+	}
+	return last.Time, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,110 @@
+package documents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/sheets/v4"
+)
+
+const (
+	credentialsFile = "keys/credentials.json"
+	tokenFile       = "keys/token.json"
+)
+
+// Token lấy token OAuth qua trình duyệt và lưu vào keys/token.json để dùng sau này.
+func Token(ctx context.Context) error {
+	// Đọc file credentials.json
+	b, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return fmt.Errorf("không đọc được credentials.json: %w", err)
+	}
+
+	// Tạo OAuth config
+	config, err := google.ConfigFromJSON(b, "https://www.googleapis.com/auth/drive")
+	if err != nil {
+		return fmt.Errorf("không parse được credentials.json: %w", err)
+	}
+
+	// Lấy token (qua trình duyệt)
+	tok, err := getTokenFromWeb(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	// Lưu token.json để dùng sau này
+	if err := saveToken(tokenFile, tok); err != nil {
+		return err
+	}
+	slog.Info("đã lưu token", "path", tokenFile)
+	return nil
+}
+
+// getTokenFromWeb mở trình duyệt để người dùng xác nhận quyền
+func getTokenFromWeb(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
+	fmt.Printf("🔗 Mở link sau để xác thực:\n%v\n\n", authURL)
+
+	fmt.Print("👉 Nhập mã xác thực (authorization code) từ trình duyệt: ")
+	var authCode string
+	if _, err := fmt.Scan(&authCode); err != nil {
+		return nil, fmt.Errorf("không đọc được mã xác thực: %w", err)
+	}
+
+	tok, err := config.Exchange(ctx, authCode)
+	if err != nil {
+		return nil, fmt.Errorf("không đổi được token: %w", err)
+	}
+	return tok, nil
+}
+
+// saveToken ghi token ra file token.json
+func saveToken(path string, token *oauth2.Token) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("không ghi được file token: %w", err)
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(token)
+}
+
+// ---- Hàm OAuth ----
+func getClient(ctx context.Context) (*http.Client, error) {
+	b, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("không đọc được credentials.json: %w", err)
+	}
+
+	config, err := google.ConfigFromJSON(b, drive.DriveFileScope, sheets.SpreadsheetsScope)
+	if err != nil {
+		return nil, fmt.Errorf("không parse được credentials.json: %w", err)
+	}
+
+	tok, err := getTokenFromFile(tokenFile)
+	if err != nil {
+		return nil, err
+	}
+	return config.Client(ctx, tok), nil
+}
+
+func getTokenFromFile(file string) (*oauth2.Token, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("không mở được %s: %w", file, err)
+	}
+	defer f.Close()
+
+	var token oauth2.Token
+	err = json.NewDecoder(f).Decode(&token)
+	if err != nil {
+		return nil, fmt.Errorf("không parse được token.json: %w", err)
+	}
+	return &token, nil
+}
@@ -1,17 +1,15 @@
-package main
+// Package documents tải tài liệu theo danh sách trong Google Sheets lên Google Drive.
+package documents
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
+	"webcrawler/logging"
 
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/option"
 	"google.golang.org/api/sheets/v4"
@@ -28,27 +26,33 @@ var (
 	driveSvc *drive.Service
 )
 
-func main() {
-	ctx := context.Background()
-	client := getClient(ctx)
+// Sync đọc các sheet, tải file chưa đánh dấu "x" lên Drive rồi đánh dấu đã tải.
+func Sync(ctx context.Context) error {
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
 
-	var err error
 	sheetSvc, err = sheets.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
-		log.Fatalf("Không tạo được Sheets service: %v", err)
+		return fmt.Errorf("không tạo được Sheets service: %w", err)
 	}
 
 	driveSvc, err = drive.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
-		log.Fatalf("Không tạo được Drive service: %v", err)
+		return fmt.Errorf("không tạo được Drive service: %w", err)
 	}
 
 	sheetsList := []string{"Lớp 5", "Lớp 9", "Lớp 12"}
 
 	for _, sh := range sheetsList {
-		fmt.Printf("\n📘 Đang xử lý sheet: %s\n", sh)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		slog.Info("đang xử lý sheet", "sheet", sh)
 		readSheet(ctx, sh)
 	}
+	return nil
 }
 
 // ---- Đọc và xử lý dữ liệu trong sheet ----
@@ -56,7 +60,7 @@ func readSheet(ctx context.Context, sheetName string) {
 	readRange := fmt.Sprintf("%s!A2:G", sheetName)
 	resp, err := sheetSvc.Spreadsheets.Values.Get(spreadsheetID, readRange).Do()
 	if err != nil {
-		log.Printf("❌ Lỗi sheet %s: không đọc được sheet: %v", sheetName, err)
+		slog.Error("không đọc được sheet", "sheet", sheetName, "error", err)
 		return
 	}
 
@@ -98,20 +102,22 @@ func processPublisher(sheetName, subject, publisher string, row []interface{}, r
 		return
 	}
 
+	logger := slog.With("sheet", sheetName, "subject", subject, "publisher", publisher, "row", rowNum, logging.KeyURL, link)
+
 	// Nếu đã có "x" thì bỏ qua file này
 	if status == "x" {
-		log.Printf("✅ Bỏ qua file đã tải: [Sheet: %s] [Môn: %s] [NXB: %s] [Dòng: %d]", sheetName, subject, publisher, rowNum)
+		logger.Debug("bỏ qua file đã tải")
 		return
 	}
 
 	// Nếu chưa có "x" → tiến hành tải và upload
-	log.Printf("⬇️  Đang tải file: [Sheet: %s] [Môn: %s] [NXB: %s] [Dòng: %d] → %s", sheetName, subject, publisher, rowNum, link)
+	logger.Info("đang tải file", logging.KeyStage, "download")
 	err := downloadAndUpload(sheetName, subject, publisher, link)
 	if err != nil {
-		log.Printf("⚠️  Không tải được file: [Sheet: %s] [Môn: %s] [NXB: %s] [Dòng: %d] | Lỗi: %v", sheetName, subject, publisher, rowNum, err)
+		logger.Warn("không tải được file", logging.KeyStage, "download", "error", err)
 	} else {
 		markDownloaded(sheetName, rowNum, markCol)
-		log.Printf("✅ Hoàn tất: [Sheet: %s] [Môn: %s] [NXB: %s] [Dòng: %d]", sheetName, subject, publisher, rowNum)
+		logger.Info("hoàn tất", logging.KeyStage, "upload")
 	}
 }
 
@@ -128,9 +134,18 @@ func downloadAndUpload(sheetName, subject, publisher, url string) error {
 	}
 
 	fileName := filepath.Base(url)
-	classFolderID := ensureFolderExists(sheetName, rootFolderID)
-	subjectFolderID := ensureFolderExists(subject, classFolderID)
-	pubFolderID := ensureFolderExists(publisher, subjectFolderID)
+	classFolderID, err := ensureFolderExists(sheetName, rootFolderID)
+	if err != nil {
+		return err
+	}
+	subjectFolderID, err := ensureFolderExists(subject, classFolderID)
+	if err != nil {
+		return err
+	}
+	pubFolderID, err := ensureFolderExists(publisher, subjectFolderID)
+	if err != nil {
+		return err
+	}
 
 	driveFile := &drive.File{
 		Name:    fileName,
@@ -145,11 +160,11 @@ func downloadAndUpload(sheetName, subject, publisher, url string) error {
 }
 
 // ---- Tạo folder nếu chưa tồn tại ----
-func ensureFolderExists(name, parentID string) string {
+func ensureFolderExists(name, parentID string) (string, error) {
 	q := fmt.Sprintf("name='%s' and mimeType='application/vnd.google-apps.folder' and '%s' in parents and trashed=false", name, parentID)
 	r, err := driveSvc.Files.List().Q(q).Fields("files(id, name)").Do()
 	if err == nil && len(r.Files) > 0 {
-		return r.Files[0].Id
+		return r.Files[0].Id, nil
 	}
 
 	folder := &drive.File{
@@ -159,9 +174,9 @@ func ensureFolderExists(name, parentID string) string {
 	}
 	created, err := driveSvc.Files.Create(folder).Do()
 	if err != nil {
-		log.Fatalf("Không tạo được thư mục %s: %v", name, err)
+		return "", fmt.Errorf("không tạo được thư mục %s: %w", name, err)
 	}
-	return created.Id
+	return created.Id, nil
 }
 
 // ---- Đánh dấu X sau khi tải ----
@@ -173,41 +188,10 @@ func markDownloaded(sheetName string, row int, col string) {
 	_, err := sheetSvc.Spreadsheets.Values.Update(spreadsheetID, writeRange, valueRange).
 		ValueInputOption("RAW").Do()
 	if err != nil {
-		log.Printf("⚠️ Không ghi được dấu x tại %s: %v", writeRange, err)
+		slog.Warn("không ghi được dấu x", logging.KeyStage, "mark", "range", writeRange, "error", err)
 	} else {
-		log.Printf("✏️ Đánh dấu x tại %s", writeRange)
-	}
-}
-
-// ---- Hàm OAuth ----
-func getClient(ctx context.Context) *http.Client {
-	b, err := os.ReadFile("keys/credentials.json")
-	if err != nil {
-		log.Fatalf("Không đọc được credentials.json: %v", err)
-	}
-
-	config, err := google.ConfigFromJSON(b, drive.DriveFileScope, sheets.SpreadsheetsScope)
-	if err != nil {
-		log.Fatalf("Không parse được credentials.json: %v", err)
-	}
-
-	tok := getTokenFromFile("keys/token.json")
-	return config.Client(ctx, tok)
-}
-
-func getTokenFromFile(file string) *oauth2.Token {
-	f, err := os.Open(file)
-	if err != nil {
-		log.Fatalf("Không mở được %s: %v", file, err)
-	}
-	defer f.Close()
-
-	var token oauth2.Token
-	err = json.NewDecoder(f).Decode(&token)
-	if err != nil {
-		log.Fatalf("Không parse được token.json: %v", err)
+		slog.Info("đã đánh dấu x", logging.KeyStage, "mark", "range", writeRange)
 	}
-	return &token
 }
 
 // ---- Tiện ích ----
@@ -0,0 +1,214 @@
+// Package extract bóc tách thông tin tuyển dụng từ nội dung thông báo.
+package extract
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"webcrawler/helpers"
+)
+
+// Recruitment là thông tin tuyển dụng trích được từ một thông báo.
+type Recruitment struct {
+	Organization string      `json:"organization,omitempty"`
+	Positions    int         `json:"positions,omitempty"`
+	JobTitles    []string    `json:"job_titles,omitempty"`
+	Deadline     *time.Time  `json:"deadline,omitempty"`
+	ExamDates    []time.Time `json:"exam_dates,omitempty"`
+	Contact      string      `json:"contact,omitempty"`
+}
+
+var (
+	orgLabelRe = regexp.MustCompile(`(?i)(?:cơ quan|đơn vị)\s+(?:tuyển dụng|tổ chức tuyển dụng)\s*[:\-]\s*(.+)`)
+	orgLineRe  = regexp.MustCompile(`^(?:BỘ|SỞ|VIỆN|HỌC VIỆN|TRƯỜNG|BỆNH VIỆN|TRUNG TÂM|HỘI|ỦY BAN|UBND|CỤC|TỔNG CỤC|BAN|ĐẠI HỌC)\s+\p{Lu}`)
+
+	positionsRes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)(?:tổng\s+)?chỉ\s+tiêu(?:\s+tuyển\s+dụng)?[^0-9\n]{0,30}?(\d{1,4})`),
+		regexp.MustCompile(`(?i)tuyển\s+dụng\s+(\d{1,4})\s+(?:chỉ tiêu|viên chức|người|vị trí|công chức|nhân viên)`),
+		regexp.MustCompile(`(?i)số\s+lượng(?:\s+cần\s+tuyển)?[^0-9\n]{0,20}?(\d{1,4})`),
+	}
+	// năm của đợt tuyển ("Chỉ tiêu tuyển dụng năm 2025") không phải số chỉ tiêu
+	positionsYearRe = regexp.MustCompile(`(?i)năm\s+(?:19|20)\d{2}\b`)
+	jobTitleRe      = regexp.MustCompile(`(?i)(?:vị trí việc làm|vị trí tuyển dụng|vị trí cần tuyển|chức danh)\s*[:\-]\s*(.+)`)
+
+	deadlineRe = regexp.MustCompile(`(?i)hạn\s+(?:cuối\s+)?nộp\s+hồ\s+sơ|thời\s+hạn\s+(?:nhận|nộp)\s+hồ\s+sơ|thời\s+gian\s+(?:nhận|nộp)\s+hồ\s+sơ|hạn\s+cuối`)
+	examRe     = regexp.MustCompile(`(?i)thời\s+gian\s+(?:tổ\s+chức\s+)?(?:thi|xét|kiểm\s+tra|phỏng\s+vấn)|ngày\s+thi|lịch\s+thi|tổ\s+chức\s+(?:thi|xét|phỏng\s+vấn)`)
+
+	contactRe = regexp.MustCompile(`(?i)liên\s+hệ|điện\s+thoại|đt\s*:|email|e-mail`)
+	phoneRe   = regexp.MustCompile(`(?:\+84|0)\d{1,3}[\s.]?\d{3,4}[\s.]?\d{3,4}`)
+	emailRe   = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+)
+
+// số ký tự sau cụm từ khóa được dùng để tìm ngày
+const dateWindow = 200
+
+// FromText trích thông tin tuyển dụng từ text thuần của thông báo (mỗi khối một dòng).
+func FromText(text string) Recruitment {
+	var r Recruitment
+	lines := strings.Split(text, "\n")
+
+	r.Organization = findOrganization(lines)
+	r.Positions = findPositions(text)
+	r.JobTitles = findJobTitles(lines)
+
+	if dates := datesAfter(text, deadlineRe); len(dates) > 0 {
+		// "từ ngày .. đến ngày .." thì hạn là ngày cuối cùng
+		deadline := dates[len(dates)-1]
+		r.Deadline = &deadline
+	}
+	r.ExamDates = datesAfter(text, examRe)
+	r.Contact = findContact(text)
+	return r
+}
+
+// IsEmpty cho biết không trích được thông tin nào.
+func (r Recruitment) IsEmpty() bool {
+	return r.Organization == "" && r.Positions == 0 && len(r.JobTitles) == 0 &&
+		r.Deadline == nil && len(r.ExamDates) == 0 && r.Contact == ""
+}
+
+// Subject trả về phần tóm tắt gắn vào tiêu đề email, rỗng nếu không có gì đáng kể.
+func (r Recruitment) Subject() string {
+	var parts []string
+	if r.Positions > 0 {
+		parts = append(parts, fmt.Sprintf("%d chỉ tiêu", r.Positions))
+	}
+	if r.Deadline != nil {
+		parts = append(parts, "hạn nộp "+r.Deadline.Format("02/01/2006"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// HTML trả về bảng tóm tắt để chèn vào đầu email.
+func (r Recruitment) HTML() string {
+	if r.IsEmpty() {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(`<table border="1" cellpadding="4" style="border-collapse:collapse;margin-bottom:12px">`)
+	row := func(label, value string) {
+		if value == "" {
+			return
+		}
+		fmt.Fprintf(&b, "<tr><th align=\"left\">%s</th><td>%s</td></tr>", label, html.EscapeString(value))
+	}
+	row("Đơn vị tuyển dụng", r.Organization)
+	if r.Positions > 0 {
+		row("Chỉ tiêu", strconv.Itoa(r.Positions))
+	}
+	row("Vị trí", strings.Join(r.JobTitles, "; "))
+	if r.Deadline != nil {
+		row("Hạn nộp hồ sơ", r.Deadline.Format("02/01/2006"))
+	}
+	row("Thời gian thi", FormatDates(r.ExamDates))
+	row("Liên hệ", r.Contact)
+	b.WriteString("</table>")
+	return b.String()
+}
+
+// FormatDates nối các ngày theo định dạng dd/mm/yyyy.
+func FormatDates(dates []time.Time) string {
+	out := make([]string, len(dates))
+	for i, d := range dates {
+		out[i] = d.Format("02/01/2006")
+	}
+	return strings.Join(out, ", ")
+}
+
+func findOrganization(lines []string) string {
+	for _, line := range lines {
+		if m := orgLabelRe.FindStringSubmatch(line); m != nil {
+			return cleanValue(m[1])
+		}
+	}
+	// phần đầu văn bản ghi cơ quan chủ quản rồi mới đến đơn vị ban hành, lấy dòng sau cùng
+	org := ""
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if orgLineRe.MatchString(line) && line == strings.ToUpper(line) {
+			org = line
+		} else if org != "" {
+			break
+		}
+	}
+	return cleanValue(org)
+}
+
+func findPositions(text string) int {
+	text = positionsYearRe.ReplaceAllString(text, "năm")
+	for _, re := range positionsRes {
+		if m := re.FindStringSubmatch(text); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			return n
+		}
+	}
+	return 0
+}
+
+func findJobTitles(lines []string) []string {
+	var titles []string
+	seen := map[string]bool{}
+	for _, line := range lines {
+		m := jobTitleRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		for _, t := range strings.Split(m[1], ";") {
+			t = cleanValue(t)
+			if t != "" && !seen[t] {
+				seen[t] = true
+				titles = append(titles, t)
+			}
+		}
+	}
+	return titles
+}
+
+// datesAfter trả về các ngày nằm ngay sau lần xuất hiện đầu tiên có ngày của re.
+func datesAfter(text string, re *regexp.Regexp) []time.Time {
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		end := loc[1] + dateWindow
+		if end > len(text) {
+			end = len(text)
+		}
+		// ngày nằm trên dòng chứa cụm từ khóa, hoặc dòng kế tiếp nếu dòng đó không có ngày;
+		// không xét xa hơn để không lấy nhầm ngày của mục khác
+		lines := strings.SplitN(text[loc[1]:end], "\n", 3)
+		for _, line := range lines[:min(len(lines), 2)] {
+			if dates := helpers.FindDates(line); len(dates) > 0 {
+				return dates
+			}
+		}
+	}
+	return nil
+}
+
+func findContact(text string) string {
+	var parts []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(text, "\n") {
+		if !contactRe.MatchString(line) {
+			continue
+		}
+		for _, v := range append(phoneRe.FindAllString(line, -1), emailRe.FindAllString(line, -1)...) {
+			v = strings.TrimSpace(v)
+			if !seen[v] {
+				seen[v] = true
+				parts = append(parts, v)
+			}
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func cleanValue(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.TrimRight(s, ".;,")
+	if r := []rune(s); len(r) > 200 {
+		s = string(r[:200])
+	}
+	return s
+}
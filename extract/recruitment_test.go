@@ -0,0 +1,99 @@
+package extract
+
+import (
+	"testing"
+	"time"
+	"webcrawler/helpers"
+)
+
+func date(d, m, y int) time.Time {
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, helpers.Location)
+}
+
+func TestPositions(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"Tổng chỉ tiêu tuyển dụng: 15 chỉ tiêu", 15},
+		{"Chỉ tiêu tuyển dụng năm 2025: 08 người", 8},
+		{"Chỉ tiêu tuyển dụng năm 2025\nVị trí việc làm: Chuyên viên", 0},
+		{"Kế hoạch tuyển dụng viên chức năm 2025\nTổng chỉ tiêu là 12 người", 12},
+		{"Viện Hàn lâm thông báo tuyển dụng 03 viên chức năm 2024", 3},
+		{"Số lượng cần tuyển: 02 người (01 kế toán, 01 văn thư)", 2},
+		{"Thông báo tuyển dụng năm 2025 của Trung tâm", 0},
+	}
+	for _, tt := range tests {
+		if got := FromText(tt.text).Positions; got != tt.want {
+			t.Errorf("%q: Positions = %d, muốn %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	tests := []struct {
+		text string
+		want *time.Time
+	}{
+		{"Hạn nộp hồ sơ: 17h00 ngày 20/03/2025", ptr(date(20, 3, 2025))},
+		{"Thời gian nhận hồ sơ: từ ngày 01/03/2025 đến hết ngày 30/03/2025 (trong giờ hành chính)", ptr(date(30, 3, 2025))},
+		{"3. Thời hạn nộp hồ sơ\nTrước ngày 15 tháng 4 năm 2025", ptr(date(15, 4, 2025))},
+		// ngày ban hành ở đầu văn bản không phải hạn nộp
+		{"Hà Nội, ngày 05/03/2025\nHồ sơ nộp trực tiếp tại Phòng Tổ chức cán bộ", nil},
+	}
+	for _, tt := range tests {
+		got := FromText(tt.text).Deadline
+		if (got == nil) != (tt.want == nil) || got != nil && !got.Equal(*tt.want) {
+			t.Errorf("%q: Deadline = %v, muốn %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestExamDates(t *testing.T) {
+	tests := []struct {
+		text string
+		want []time.Time
+	}{
+		{"Thời gian tổ chức thi: dự kiến ngày 12/04/2025", []time.Time{date(12, 4, 2025)}},
+		{"Lịch thi: vòng 1 ngày 12/04/2025, vòng 2 ngày 19/04/2025", []time.Time{date(12, 4, 2025), date(19, 4, 2025)}},
+		{"Thời gian xét tuyển\nNgày 05 tháng 5 năm 2025 tại Hội trường tầng 3", []time.Time{date(5, 5, 2025)}},
+		// chỉ xét dòng chứa cụm từ khóa và dòng kế tiếp
+		{"Thời gian thi: sẽ thông báo sau\nĐịa điểm: Hội trường\nHạn nộp hồ sơ: 20/03/2025", nil},
+	}
+	for _, tt := range tests {
+		got := FromText(tt.text).ExamDates
+		if FormatDates(got) != FormatDates(tt.want) {
+			t.Errorf("%q: ExamDates = %s, muốn %s", tt.text, FormatDates(got), FormatDates(tt.want))
+		}
+	}
+}
+
+func TestContact(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Mọi chi tiết xin liên hệ: Phòng Tổ chức cán bộ, điện thoại 024.3825.3536", "024.3825.3536"},
+		{"Điện thoại: 0912 345 678; Email: tccb@vass.gov.vn", "0912 345 678, tccb@vass.gov.vn"},
+		{"ĐT: 024 3943 1234\nLiên hệ: 024 3943 1234", "024 3943 1234"},
+		// số trên dòng không phải dòng liên hệ bị bỏ qua
+		{"Mã số chức danh: 01.003\nSố 26 Lý Thường Kiệt, Hà Nội", ""},
+	}
+	for _, tt := range tests {
+		if got := FromText(tt.text).Contact; got != tt.want {
+			t.Errorf("%q: Contact = %q, muốn %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSubject(t *testing.T) {
+	r := FromText("Tổng chỉ tiêu: 05 người\nHạn nộp hồ sơ: 20/03/2025")
+	if got, want := r.Subject(), "5 chỉ tiêu, hạn nộp 20/03/2025"; got != want {
+		t.Errorf("Subject = %q, muốn %q", got, want)
+	}
+	if !FromText("Thông báo nghỉ lễ").IsEmpty() {
+		t.Error("thông báo không có thông tin tuyển dụng phải rỗng")
+	}
+}
+
+func ptr(t time.Time) *time.Time { return &t }
@@ -0,0 +1,269 @@
+// Package feed tạo feed Atom/RSS từ các tin đã lưu để theo dõi bằng trình đọc tin.
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"webcrawler/config"
+	"webcrawler/helpers"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// mỗi feed giữ tối đa chừng này tin mới nhất
+const maxEntries = 200
+
+// Format là định dạng feed.
+type Format string
+
+const (
+	// Atom là định dạng Atom 1.0 (RFC 4287)
+	Atom Format = "atom"
+	// RSS là định dạng RSS 2.0
+	RSS Format = "rss"
+)
+
+// ContentType là header Content-Type khi phục vụ feed qua HTTP.
+func (f Format) ContentType() string {
+	if f == RSS {
+		return "application/rss+xml; charset=utf-8"
+	}
+	return "application/atom+xml; charset=utf-8"
+}
+
+// Filter chọn tin đưa vào feed, trường rỗng là không lọc.
+type Filter struct {
+	Site    string
+	Keyword string // khớp không phân biệt hoa thường trong tiêu đề hoặc nội dung
+}
+
+func (f Filter) match(a config.Article) bool {
+	if f.Site != "" && a.Site != f.Site {
+		return false
+	}
+	if f.Keyword == "" {
+		return true
+	}
+	kw := strings.ToLower(f.Keyword)
+	return strings.Contains(strings.ToLower(a.Title), kw) ||
+		strings.Contains(strings.ToLower(helpers.HTMLToText(a.Content)), kw)
+}
+
+// Title là tên feed theo bộ lọc.
+func (f Filter) Title() string {
+	title := "Thông báo tuyển dụng"
+	if f.Site != "" {
+		title += " - " + f.Site
+	}
+	if f.Keyword != "" {
+		title += " - “" + f.Keyword + "”"
+	}
+	return title
+}
+
+// Build tạo feed gồm các tin được lưu từ since khớp bộ lọc, mới nhất trước.
+// selfURL là địa chỉ của chính feed, có thể rỗng.
+func Build(since time.Time, f Filter, format Format, selfURL string) ([]byte, error) {
+	articles, err := config.ListArticles(since)
+	if err != nil {
+		return nil, err
+	}
+	return Render(f, format, selfURL, articles)
+}
+
+// Render tạo feed từ các tin đã đọc, dùng khi tạo nhiều feed từ cùng một lần đọc database.
+func Render(f Filter, format Format, selfURL string, articles []config.Article) ([]byte, error) {
+	var matched []config.Article
+	for _, a := range articles {
+		if f.match(a) {
+			matched = append(matched, a)
+			if len(matched) == maxEntries {
+				break
+			}
+		}
+	}
+	var doc any
+	if format == RSS {
+		doc = rssDoc(f.Title(), selfURL, matched)
+	} else {
+		doc = atomDoc(f.Title(), selfURL, matched)
+	}
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("lỗi tạo feed: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// Slug chuyển tên (site, từ khóa) thành tên file: chữ thường không dấu, nối bằng "-".
+func Slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == 'đ':
+			r = 'd'
+		case r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'):
+			if b.Len() > 0 {
+				dash = true
+			}
+			continue
+		}
+		if dash {
+			b.WriteByte('-')
+			dash = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// published là ngày đăng của tin, không biết thì lấy lúc lưu.
+func published(a config.Article) time.Time {
+	if !a.PublishedAt.IsZero() {
+		return a.PublishedAt
+	}
+	return a.CreatedAt
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title     string        `xml:"title"`
+	ID        string        `xml:"id"`
+	Link      atomLink      `xml:"link"`
+	Published string        `xml:"published"`
+	Updated   string        `xml:"updated"`
+	Category  *atomCategory `xml:"category,omitempty"`
+	Summary   string        `xml:"summary,omitempty"`
+	Content   atomContent   `xml:"content"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+func atomDoc(title, selfURL string, articles []config.Article) atomFeed {
+	f := atomFeed{
+		Title:  title,
+		ID:     "urn:webcrawler:feed:" + Slug(title),
+		Author: atomAuthor{Name: "webcrawler"},
+	}
+	if selfURL != "" {
+		f.ID = selfURL
+		f.Links = append(f.Links, atomLink{Rel: "self", Href: selfURL})
+	}
+	// feed rỗng vẫn cần updated, lấy lúc tạo feed
+	updated := helpers.Now()
+	if len(articles) > 0 {
+		updated = articles[0].CreatedAt
+	}
+	f.Updated = updated.Format(time.RFC3339)
+	for _, a := range articles {
+		e := atomEntry{
+			Title:     a.Title,
+			ID:        a.URL,
+			Link:      atomLink{Rel: "alternate", Href: a.URL},
+			Published: published(a).Format(time.RFC3339),
+			Updated:   a.CreatedAt.Format(time.RFC3339),
+			Summary:   a.Recruitment.Subject(), // tóm tắt chỉ tiêu, hạn nộp như trong tiêu đề email
+			Content:   atomContent{Type: "html", Body: a.Recruitment.HTML() + a.Content},
+		}
+		if a.Site != "" {
+			e.Category = &atomCategory{Term: a.Site}
+		}
+		f.Entries = append(f.Entries, e)
+	}
+	return f
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr,omitempty"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link,omitempty"`
+	AtomLink    *rssSelf  `xml:"atom:link,omitempty"`
+	Description string    `xml:"description"`
+	Language    string    `xml:"language"`
+	PubDate     string    `xml:"pubDate,omitempty"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssSelf struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Category    string  `xml:"category,omitempty"`
+	Description string  `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func rssDoc(title, selfURL string, articles []config.Article) rssFeed {
+	f := rssFeed{Version: "2.0", Channel: rssChannel{
+		Title:       title,
+		Link:        selfURL,
+		Description: title + " thu thập từ các site theo dõi",
+		Language:    "vi",
+	}}
+	if selfURL != "" {
+		f.AtomNS = "http://www.w3.org/2005/Atom"
+		f.Channel.AtomLink = &rssSelf{Href: selfURL, Rel: "self", Type: "application/rss+xml"}
+	}
+	if len(articles) > 0 {
+		f.Channel.PubDate = articles[0].CreatedAt.Format(time.RFC1123Z)
+	}
+	for _, a := range articles {
+		f.Channel.Items = append(f.Channel.Items, rssItem{
+			Title:       a.Title,
+			Link:        a.URL,
+			GUID:        rssGUID{IsPermaLink: "true", Value: a.URL},
+			PubDate:     published(a).Format(time.RFC1123Z),
+			Category:    a.Site,
+			Description: a.Recruitment.HTML() + a.Content,
+		})
+	}
+	return f
+}
@@ -0,0 +1,231 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"webcrawler/config"
+	"webcrawler/helpers"
+)
+
+var testTime = time.Date(2025, 3, 5, 14, 30, 0, 0, helpers.Location)
+
+// testArticles trả về n tin của site hvtp, mới nhất trước như ListArticles.
+func testArticles(n int) []config.Article {
+	out := make([]config.Article, n)
+	for i := range out {
+		out[i] = config.Article{
+			URL:       fmt.Sprintf("https://hvtp.edu.vn/tb-%d.html", i),
+			Site:      "hvtp",
+			Title:     fmt.Sprintf("Thông báo số %d", i),
+			Content:   "<p>Nội dung thông báo</p>",
+			CreatedAt: testTime.Add(-time.Duration(i) * time.Hour),
+		}
+	}
+	return out
+}
+
+func parseAtom(t *testing.T, data []byte) atomFeed {
+	t.Helper()
+	var f atomFeed
+	if err := xml.Unmarshal(data, &f); err != nil {
+		t.Fatalf("feed Atom không đọc được: %v\n%s", err, data)
+	}
+	return f
+}
+
+func parseRSS(t *testing.T, data []byte) rssFeed {
+	t.Helper()
+	var f rssFeed
+	if err := xml.Unmarshal(data, &f); err != nil {
+		t.Fatalf("feed RSS không đọc được: %v\n%s", err, data)
+	}
+	return f
+}
+
+func TestRender(t *testing.T) {
+	articles := []config.Article{{
+		URL:         "https://vca.org.vn/tb?id=1&p=2",
+		Site:        "vca_news",
+		Title:       "Thông báo tuyển dụng <Kế toán> & văn thư",
+		PublishedAt: testTime.AddDate(0, 0, -1),
+		Content:     "<p>Hạn nộp hồ sơ: 20/03/2025</p>",
+		CreatedAt:   testTime,
+	}}
+	self := "https://example.vn/feeds/all.atom"
+
+	atom, err := Render(Filter{}, Atom, self, articles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := parseAtom(t, atom)
+	if a.ID != self || len(a.Links) != 1 || a.Links[0].Href != self || a.Updated != testTime.Format(time.RFC3339) {
+		t.Errorf("đầu feed Atom sai: %+v", a)
+	}
+	if len(a.Entries) != 1 {
+		t.Fatalf("Atom có %d tin, muốn 1", len(a.Entries))
+	}
+	e := a.Entries[0]
+	if e.Title != articles[0].Title || e.ID != articles[0].URL || e.Link.Href != articles[0].URL ||
+		e.Published != "2025-03-04T14:30:00+07:00" || e.Category == nil || e.Category.Term != "vca_news" ||
+		e.Content.Type != "html" || !strings.Contains(e.Content.Body, articles[0].Content) {
+		t.Errorf("tin Atom sai: %+v", e)
+	}
+
+	rss, err := Render(Filter{}, RSS, "", articles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := parseRSS(t, rss)
+	if r.Version != "2.0" || r.Channel.AtomLink != nil || len(r.Channel.Items) != 1 {
+		t.Fatalf("feed RSS sai: %+v", r)
+	}
+	it := r.Channel.Items[0]
+	if it.Title != articles[0].Title || it.Link != articles[0].URL || it.GUID.Value != articles[0].URL ||
+		it.PubDate != "Tue, 04 Mar 2025 14:30:00 +0700" || it.Category != "vca_news" ||
+		!strings.Contains(it.Description, articles[0].Content) {
+		t.Errorf("tin RSS sai: %+v", it)
+	}
+}
+
+func TestRenderLimit(t *testing.T) {
+	articles := testArticles(maxEntries + 50)
+	for _, format := range []Format{Atom, RSS} {
+		data, err := Render(Filter{}, format, "", articles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		if format == Atom {
+			for _, e := range parseAtom(t, data).Entries {
+				titles = append(titles, e.Title)
+			}
+		} else {
+			for _, it := range parseRSS(t, data).Channel.Items {
+				titles = append(titles, it.Title)
+			}
+		}
+		if len(titles) != maxEntries {
+			t.Fatalf("%s: %d tin, muốn %d", format, len(titles), maxEntries)
+		}
+		// giữ các tin mới nhất
+		if titles[0] != "Thông báo số 0" || titles[maxEntries-1] != fmt.Sprintf("Thông báo số %d", maxEntries-1) {
+			t.Errorf("%s: giữ sai tin, từ %q đến %q", format, titles[0], titles[maxEntries-1])
+		}
+	}
+}
+
+func TestRenderFilter(t *testing.T) {
+	articles := []config.Article{
+		{URL: "https://a.vn/1", Site: "hvtp", Title: "Thông báo THI TUYỂN viên chức", CreatedAt: testTime},
+		{URL: "https://a.vn/2", Site: "bvhh", Title: "Thông báo", Content: "<p>Kế hoạch <b>thi tuyển</b> năm 2025</p>", CreatedAt: testTime},
+		{URL: "https://a.vn/3", Site: "hvtp", Title: "Lịch nghỉ lễ", Content: "<p>Nghỉ lễ 30/4</p>", CreatedAt: testTime},
+		// từ khóa nằm trong thuộc tính HTML không tính
+		{URL: "https://a.vn/4", Site: "bvhh", Title: "Tin khác", Content: `<a href="/thi tuyển">link</a>`, CreatedAt: testTime},
+	}
+	tests := []struct {
+		filter Filter
+		want   []string
+	}{
+		{Filter{}, []string{"https://a.vn/1", "https://a.vn/2", "https://a.vn/3", "https://a.vn/4"}},
+		{Filter{Keyword: "thi tuyển"}, []string{"https://a.vn/1", "https://a.vn/2"}},
+		{Filter{Site: "hvtp"}, []string{"https://a.vn/1", "https://a.vn/3"}},
+		{Filter{Site: "hvtp", Keyword: "Thi Tuyển"}, []string{"https://a.vn/1"}},
+		{Filter{Keyword: "kế toán"}, nil},
+	}
+	for _, tt := range tests {
+		data, err := Render(tt.filter, Atom, "", articles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range parseAtom(t, data).Entries {
+			got = append(got, e.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%+v: %v, muốn %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := map[string]string{
+		"hvtp":                  "hvtp",
+		"vca_news":              "vca_news",
+		"thi tuyển":             "thi-tuyen",
+		"Kế toán":               "ke-toan",
+		"  Đào tạo, bồi dưỡng ": "dao-tao-boi-duong",
+		"Viên chức 2025":        "vien-chuc-2025",
+		"C++":                   "c",
+		"###":                   "",
+	}
+	for in, want := range tests {
+		if got := Slug(in); got != want {
+			t.Errorf("Slug(%q) = %q, muốn %q", in, got, want)
+		}
+	}
+}
+
+func TestFileFilters(t *testing.T) {
+	tests := []struct {
+		sites, keywords []string
+		ok              bool
+	}{
+		{[]string{"hvtp", "bvhh"}, []string{"thi tuyển", "kế toán"}, true},
+		// trùng y hệt thì chỉ là một feed
+		{[]string{"hvtp"}, []string{"thi tuyển", "thi tuyển"}, true},
+		// site và từ khóa khác tiền tố nên không trùng
+		{[]string{"hvtp"}, []string{"hvtp"}, true},
+		{nil, []string{"C++", "C#"}, false},
+		{nil, []string{"Kế toán", "ke toan"}, false},
+		{[]string{"vca-news", "vca news"}, nil, false},
+		{nil, []string{"+++"}, false},
+	}
+	for _, tt := range tests {
+		_, err := fileFilters(tt.sites, tt.keywords)
+		if (err == nil) != tt.ok {
+			t.Errorf("site %v, từ khóa %v: lỗi = %v", tt.sites, tt.keywords, err)
+		}
+	}
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir := t.TempDir()
+	filters, err := fileFilters([]string{"hvtp", "bvhh"}, []string{"thi tuyển"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFiles(dir, "https://example.vn/feeds", filters, testArticles(3)); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{
+		"all.atom", "all.rss", "keyword-thi-tuyen.atom", "keyword-thi-tuyen.rss",
+		"site-bvhh.atom", "site-bvhh.rss", "site-hvtp.atom", "site-hvtp.rss",
+	}
+	if !slices.Equal(names, want) {
+		t.Fatalf("file feed = %v, muốn %v", names, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "site-hvtp.atom"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := parseAtom(t, data)
+	if f.ID != "https://example.vn/feeds/site-hvtp.atom" || len(f.Entries) != 3 {
+		t.Errorf("site-hvtp.atom: id %q, %d tin", f.ID, len(f.Entries))
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "site-bvhh.rss"))
+	if r := parseRSS(t, data); len(r.Channel.Items) != 0 {
+		t.Errorf("site-bvhh.rss có %d tin, muốn 0", len(r.Channel.Items))
+	}
+}
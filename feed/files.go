@@ -0,0 +1,77 @@
+package feed
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"webcrawler/config"
+	"webcrawler/helpers"
+)
+
+// WriteFiles ghi vào dir feed tổng hợp (all), mỗi site một feed (site-<tên>) và mỗi từ khóa
+// một feed (keyword-<từ khóa không dấu>), mỗi feed cả hai định dạng .atom và .rss.
+// baseURL là địa chỉ công khai của dir, dùng cho link self, có thể rỗng.
+// Hai site hoặc hai từ khóa cho cùng tên file (vd "C++" và "C#") là lỗi, không file nào được ghi.
+func WriteFiles(dir, baseURL string, since time.Time, sites, keywords []string) error {
+	filters, err := fileFilters(sites, keywords)
+	if err != nil {
+		return err
+	}
+	articles, err := config.ListArticles(since)
+	if err != nil {
+		return err
+	}
+	return writeFiles(dir, baseURL, filters, articles)
+}
+
+// fileFilters trả về bộ lọc theo tên file (không có đuôi) của từng feed.
+func fileFilters(sites, keywords []string) (map[string]Filter, error) {
+	filters := map[string]Filter{"all": {}}
+	add := func(prefix, label string, f Filter) error {
+		slug := Slug(label)
+		if slug == "" {
+			return fmt.Errorf("không tạo được tên file feed cho %q", label)
+		}
+		name := prefix + slug
+		if prev, ok := filters[name]; ok && prev != f {
+			return fmt.Errorf("feed %s trùng tên: %q và %q", name, prev.Site+prev.Keyword, label)
+		}
+		filters[name] = f
+		return nil
+	}
+	for _, s := range sites {
+		if err := add("site-", s, Filter{Site: s}); err != nil {
+			return nil, err
+		}
+	}
+	for _, k := range keywords {
+		if err := add("keyword-", k, Filter{Keyword: k}); err != nil {
+			return nil, err
+		}
+	}
+	return filters, nil
+}
+
+func writeFiles(dir, baseURL string, filters map[string]Filter, articles []config.Article) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("lỗi tạo thư mục feed: %w", err)
+	}
+	for name, f := range filters {
+		for _, format := range []Format{Atom, RSS} {
+			file := name + "." + string(format)
+			self := ""
+			if baseURL != "" {
+				self = baseURL + "/" + file
+			}
+			data, err := Render(f, format, self, articles)
+			if err != nil {
+				return err
+			}
+			if err := helpers.WriteFileAtomic(filepath.Join(dir, file), data); err != nil {
+				return fmt.Errorf("lỗi ghi file feed: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Package healthcheck gửi tín hiệu start/success/fail theo chuẩn healthchecks.io.
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"webcrawler/logging"
+)
+
+// healthchecks.io nhận body tối đa 100KB, cắt bớt cho gọn
+const maxBody = 10000
+
+const retries = 3
+
+// khi đang dừng chỉ ping thêm một lần với hạn ngắn để không vượt quá SHUTDOWN_GRACE
+const shutdownTimeout = 3 * time.Second
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Check là một địa chỉ ping, ví dụ https://hc-ping.com/<uuid>.
+// Check rỗng thì mọi lệnh ping đều bỏ qua.
+type Check string
+
+// Start báo bắt đầu chạy (<url>/start).
+func (c Check) Start(ctx context.Context) {
+	c.ping(ctx, "/start", "")
+}
+
+// Success báo chạy thành công, body là tóm tắt kết quả.
+func (c Check) Success(ctx context.Context, body string) {
+	c.ping(ctx, "", body)
+}
+
+// Fail báo chạy lỗi (<url>/fail), body là tóm tắt lỗi.
+func (c Check) Fail(ctx context.Context, body string) {
+	c.ping(ctx, "/fail", body)
+}
+
+// Finish báo Success nếu err nil, ngược lại Fail với nội dung lỗi đặt trước summary.
+func (c Check) Finish(ctx context.Context, err error, summary string) {
+	if err == nil {
+		c.Success(ctx, summary)
+		return
+	}
+	c.Fail(ctx, strings.TrimSpace(err.Error()+"\n\n"+summary))
+}
+
+// ping không bao giờ làm hỏng lần chạy, lỗi chỉ được ghi log.
+func (c Check) ping(ctx context.Context, suffix, body string) {
+	if c == "" {
+		return
+	}
+	if len(body) > maxBody {
+		body = body[:maxBody]
+	}
+	url := strings.TrimRight(string(c), "/") + suffix
+
+	var err error
+	for attempt := 1; attempt <= retries && ctx.Err() == nil; attempt++ {
+		if err = send(ctx, url, body, client.Timeout); err == nil {
+			return
+		}
+		if attempt == retries {
+			break
+		}
+		timer := time.NewTimer(time.Duration(attempt) * time.Second)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+	if ctx.Err() != nil {
+		// vẫn báo được kết quả khi lần chạy bị hủy giữa chừng
+		if err = send(context.WithoutCancel(ctx), url, body, shutdownTimeout); err == nil {
+			return
+		}
+	}
+	slog.Warn("không ping được healthcheck", logging.KeyURL, url, "error", err)
+}
+
+func send(ctx context.Context, url, body string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("trạng thái %s", resp.Status)
+	}
+	return nil
+}
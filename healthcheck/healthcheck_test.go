@@ -0,0 +1,42 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Khi lần chạy đang dừng, ping lỗi không được chờ thử lại mà chỉ gửi thêm một lần.
+func TestPingStopsRetryingOnShutdown(t *testing.T) {
+	var pings atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pings.Add(1)
+		http.Error(w, "lỗi", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	c := Check(srv.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	c.Finish(ctx, errors.New("bị dừng"), "")
+	if n := pings.Load(); n != 1 {
+		t.Errorf("ctx đã hủy: %d lần ping, muốn 1", n)
+	}
+
+	// bị hủy trong lúc chờ thử lại: dừng chờ, ping thêm một lần rồi thôi
+	pings.Store(0)
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	c.Fail(ctx, "")
+	if n := pings.Load(); n != 2 {
+		t.Errorf("hủy khi chờ: %d lần ping, muốn 2", n)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("ping mất %v khi đang dừng", d)
+	}
+}
@@ -2,24 +2,199 @@ package helpers
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
-func DiffDateToday(date string) (int, error) {
-	// format dd/mm/yyyy
-	layout := "02/01/2006"
-	parsedDate, err := time.Parse(layout, date)
+// Location là múi giờ dùng để parse và so sánh ngày (Asia/Ho_Chi_Minh).
+var Location = loadLocation()
+
+// Now là đồng hồ dùng cho mọi phép tính ngày, có thể thay thế khi test.
+var Now = time.Now
+
+func loadLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Ho_Chi_Minh")
 	if err != nil {
-		fmt.Println("Lỗi parse ngày:", err)
-		return 0, fmt.Errorf("lỗi parse ngày: %v", err)
+		// image không có tzdata, Việt Nam không dùng giờ mùa hè nên UTC+7 là đủ
+		return time.FixedZone("ICT", 7*60*60)
+	}
+	return loc
+}
+
+// SetClock thay đồng hồ hiện tại và trả về hàm khôi phục đồng hồ cũ.
+func SetClock(now func() time.Time) (restore func()) {
+	prev := Now
+	Now = now
+	return func() { Now = prev }
+}
+
+// Today trả về 0h của ngày hiện tại theo múi giờ Location.
+func Today() time.Time {
+	return startOfDay(Now().In(Location))
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// các layout đã gặp trên các site, layout dài hơn phải đứng trước
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"02/01/2006 - 15:04",
+	"15:04 02/01/2006",
+	"15:04, 02/01/2006",
+	"15:04 - 02/01/2006",
+	"02/01/2006",
+	"02-01-2006 15:04:05",
+	"02-01-2006 15:04",
+	"02-01-2006",
+	"02.01.2006",
+	// năm hai chữ số: 69-99 là 19xx, 00-68 là 20xx
+	"02/01/06",
+	"02-01-06",
+}
+
+var (
+	spaceRe    = regexp.MustCompile(`\s+`)
+	longDateRe = regexp.MustCompile(`ngày\s*(\d{1,2})\s*tháng\s*(\d{1,2})\s*năm\s*(\d{4})`)
+	relativeRe = regexp.MustCompile(`(\d+)\s*(giây|phút|giờ|tiếng|ngày|tuần|tháng|năm)\s*trước`)
+	numDateRe  = regexp.MustCompile(`(\d{1,2})[/.\-](\d{1,2})[/.\-](\d{4})(?:\D{0,3}(\d{1,2})[:h](\d{2}))?`)
+	isoDateRe  = regexp.MustCompile(`\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2})?(?:Z|[+-]\d{2}:\d{2})?)?`)
+	timeRe     = regexp.MustCompile(`(\d{1,2})[:h](\d{2})`)
+)
+
+// ParseDate parse chuỗi ngày theo các định dạng có trên các site và trả về
+// thời điểm theo múi giờ Location. Hỗ trợ dd/mm/yyyy (có hoặc không có giờ),
+// dd-mm-yyyy, dd/mm/yy, yyyy-mm-dd, RFC3339, "Ngày 05 tháng 3 năm 2025" và các mốc
+// tương đối như "2 giờ trước", "hôm nay", "hôm qua".
+func ParseDate(s string) (time.Time, error) {
+	raw := s
+	s = strings.TrimSpace(spaceRe.ReplaceAllString(s, " "))
+	s = strings.Trim(s, "()[]")
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("lỗi parse ngày: chuỗi rỗng")
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, Location); err == nil {
+			return t.In(Location), nil
+		}
+	}
+
+	lower := strings.ToLower(s)
+	if t, ok := parseRelative(lower); ok {
+		return t, nil
+	}
+	if m := longDateRe.FindStringSubmatch(lower); m != nil {
+		hour, min := findTime(lower)
+		return buildDate(m[3], m[2], m[1], hour, min)
+	}
+	if m := isoDateRe.FindString(s); m != "" && m != s {
+		return ParseDate(m)
 	}
+	if m := numDateRe.FindStringSubmatch(s); m != nil {
+		hour, min := atoi(m[4]), atoi(m[5])
+		if m[4] == "" {
+			// giờ có thể đứng trước ngày, ví dụ "14:30 05/03/2025"
+			hour, min = findTime(s)
+		}
+		return buildDate(m[3], m[2], m[1], hour, min)
+	}
+	return time.Time{}, fmt.Errorf("lỗi parse ngày: không nhận dạng được %q", raw)
+}
+
+func parseRelative(s string) (time.Time, bool) {
+	now := Now().In(Location)
+	switch {
+	case strings.Contains(s, "vừa xong"), strings.Contains(s, "vừa mới"):
+		return now, true
+	case strings.HasPrefix(s, "hôm nay"):
+		return startOfDay(now), true
+	case strings.HasPrefix(s, "hôm qua"):
+		return startOfDay(now).AddDate(0, 0, -1), true
+	}
+
+	m := relativeRe.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, false
+	}
+	n := atoi(m[1])
+	switch m[2] {
+	case "giây":
+		return now.Add(-time.Duration(n) * time.Second), true
+	case "phút":
+		return now.Add(-time.Duration(n) * time.Minute), true
+	case "giờ", "tiếng":
+		return now.Add(-time.Duration(n) * time.Hour), true
+	case "ngày":
+		return now.AddDate(0, 0, -n), true
+	case "tuần":
+		return now.AddDate(0, 0, -7*n), true
+	case "tháng":
+		return now.AddDate(0, -n, 0), true
+	default:
+		return now.AddDate(-n, 0, 0), true
+	}
+}
+
+func findTime(s string) (int, int) {
+	m := timeRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0
+	}
+	return atoi(m[1]), atoi(m[2])
+}
+
+func buildDate(year, month, day string, hour, min int) (time.Time, error) {
+	y, m, d := atoi(year), atoi(month), atoi(day)
+	if m < 1 || m > 12 || d < 1 || d > 31 || hour > 23 || min > 59 {
+		return time.Time{}, fmt.Errorf("lỗi parse ngày: ngày không hợp lệ %s/%s/%s", day, month, year)
+	}
+	t := time.Date(y, time.Month(m), d, hour, min, 0, 0, Location)
+	if t.Day() != d {
+		return time.Time{}, fmt.Errorf("lỗi parse ngày: ngày không hợp lệ %s/%s/%s", day, month, year)
+	}
+	return t, nil
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// DaysSince trả về số ngày lịch từ t đến hôm nay theo múi giờ Location,
+// số âm nếu t nằm trong tương lai.
+func DaysSince(t time.Time) int {
+	day := startOfDay(t.In(Location))
+	today := Today()
+	// Round để không lệch nếu múi giờ có giờ mùa hè
+	return int(today.Sub(day).Round(time.Hour).Hours() / 24)
+}
+
+var anyDateRe = regexp.MustCompile(`(?i)(\d{1,2})\s*[/.\-]\s*(\d{1,2})\s*[/.\-]\s*(\d{4})|ngày\s*(\d{1,2})\s*tháng\s*(\d{1,2})\s*năm\s*(\d{4})`)
 
-	today := time.Now().Truncate(24 * time.Hour)
-	diff := 0
-	if parsedDate.After(today) {
-		diff = int(parsedDate.Sub(today).Hours() / 24)
-	} else if parsedDate.Before(today) {
-		diff = int(today.Sub(parsedDate).Hours() / 24)
+// FindDates trả về các ngày (dd/mm/yyyy hoặc "ngày .. tháng .. năm ..") xuất hiện
+// trong s theo thứ tự, bỏ qua ngày không hợp lệ.
+func FindDates(s string) []time.Time {
+	var dates []time.Time
+	for _, m := range anyDateRe.FindAllStringSubmatch(s, -1) {
+		day, month, year := m[1], m[2], m[3]
+		if year == "" {
+			day, month, year = m[4], m[5], m[6]
+		}
+		if t, err := buildDate(year, month, day, 0, 0); err == nil {
+			dates = append(dates, t)
+		}
 	}
-	return diff, nil
+	return dates
 }
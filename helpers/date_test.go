@@ -0,0 +1,152 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+// 14:30 ngày 05/03/2025 giờ Việt Nam
+var testNow = time.Date(2025, 3, 5, 14, 30, 0, 0, Location)
+
+func at(y int, m time.Month, d, hour, min int) time.Time {
+	return time.Date(y, m, d, hour, min, 0, 0, Location)
+}
+
+func TestParseDate(t *testing.T) {
+	defer SetClock(func() time.Time { return testNow })()
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		// các layout
+		{"2025-03-05T14:30:00+07:00", at(2025, 3, 5, 14, 30)},
+		{"2025-03-05T07:30:00Z", at(2025, 3, 5, 14, 30)},
+		{"2025-03-05T14:30:00", at(2025, 3, 5, 14, 30)},
+		{"2025-03-05 14:30:00", at(2025, 3, 5, 14, 30)},
+		{"2025-03-05 14:30", at(2025, 3, 5, 14, 30)},
+		{"2025-03-05", at(2025, 3, 5, 0, 0)},
+		{"05/03/2025 14:30:00", at(2025, 3, 5, 14, 30)},
+		{"05/03/2025 14:30", at(2025, 3, 5, 14, 30)},
+		{"05/03/2025 - 14:30", at(2025, 3, 5, 14, 30)},
+		{"14:30 05/03/2025", at(2025, 3, 5, 14, 30)},
+		{"14:30, 05/03/2025", at(2025, 3, 5, 14, 30)},
+		{"14:30 - 05/03/2025", at(2025, 3, 5, 14, 30)},
+		{"05/03/2025", at(2025, 3, 5, 0, 0)},
+		{"05-03-2025 14:30:00", at(2025, 3, 5, 14, 30)},
+		{"05-03-2025 14:30", at(2025, 3, 5, 14, 30)},
+		{"05-03-2025", at(2025, 3, 5, 0, 0)},
+		{"05.03.2025", at(2025, 3, 5, 0, 0)},
+		{"  (05/03/2025)\n", at(2025, 3, 5, 0, 0)},
+
+		// năm hai chữ số
+		{"05/03/25", at(2025, 3, 5, 0, 0)},
+		{"05-03-25", at(2025, 3, 5, 0, 0)},
+		{"31/12/99", at(1999, 12, 31, 0, 0)},
+
+		// ngày nằm trong câu, bắt bằng regex
+		{"Ngày 05 tháng 3 năm 2025", at(2025, 3, 5, 0, 0)},
+		{"Hà Nội, ngày 5 tháng 03 năm 2025 lúc 9h15", at(2025, 3, 5, 9, 15)},
+		{"Đăng ngày 2025-03-05 14:30", at(2025, 3, 5, 14, 30)},
+		{"Cập nhật: 5/3/2025 14h30", at(2025, 3, 5, 14, 30)},
+		{"Thứ tư, 05/03/2025 | 14:30", at(2025, 3, 5, 14, 30)},
+		{"08:15 | 5-3-2025", at(2025, 3, 5, 8, 15)},
+
+		// mốc tương đối
+		{"Vừa xong", testNow},
+		{"Hôm nay", at(2025, 3, 5, 0, 0)},
+		{"hôm qua", at(2025, 3, 4, 0, 0)},
+		{"30 giây trước", testNow.Add(-30 * time.Second)},
+		{"15 phút trước", at(2025, 3, 5, 14, 15)},
+		{"2 giờ trước", at(2025, 3, 5, 12, 30)},
+		{"3 tiếng trước", at(2025, 3, 5, 11, 30)},
+		{"5 ngày trước", at(2025, 2, 28, 14, 30)},
+		{"1 tuần trước", at(2025, 2, 26, 14, 30)},
+		{"2 tháng trước", at(2025, 1, 5, 14, 30)},
+		{"1 năm trước", at(2024, 3, 5, 14, 30)},
+	}
+	for _, tt := range tests {
+		got, err := ParseDate(tt.in)
+		if err != nil {
+			t.Errorf("ParseDate(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) || got.Location() != Location {
+			t.Errorf("ParseDate(%q) = %v, muốn %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"   ",
+		"không rõ",
+		"31/02/2025",
+		"29/02/2025",
+		"31/04/2025",
+		"05/13/2025",
+		"00/03/2025",
+		"Ngày 30 tháng 2 năm 2025",
+		"25:00 05/03/2025",
+		"31/02/25",
+	} {
+		if got, err := ParseDate(in); err == nil {
+			t.Errorf("ParseDate(%q) = %v, muốn lỗi", in, got)
+		}
+	}
+	if _, err := ParseDate("29/02/2024"); err != nil {
+		t.Errorf("29/02 năm nhuận: %v", err)
+	}
+}
+
+// Quanh nửa đêm giờ Việt Nam ngày UTC vẫn là ngày hôm trước, ngày phải tính theo Location.
+func TestMidnight(t *testing.T) {
+	tests := []struct {
+		now       time.Time
+		today     time.Time
+		yesterday time.Time
+		since     int // DaysSince(05/03/2025)
+	}{
+		{at(2025, 3, 5, 23, 59), at(2025, 3, 5, 0, 0), at(2025, 3, 4, 0, 0), 0},
+		{at(2025, 3, 6, 0, 0), at(2025, 3, 6, 0, 0), at(2025, 3, 5, 0, 0), 1},
+		// 17:10 UTC ngày 05/03 là 00:10 ngày 06/03 giờ Việt Nam
+		{time.Date(2025, 3, 5, 17, 10, 0, 0, time.UTC), at(2025, 3, 6, 0, 0), at(2025, 3, 5, 0, 0), 1},
+		{time.Date(2025, 3, 5, 16, 59, 0, 0, time.UTC), at(2025, 3, 5, 0, 0), at(2025, 3, 4, 0, 0), 0},
+	}
+	for _, tt := range tests {
+		restore := SetClock(func() time.Time { return tt.now })
+		if got := Today(); !got.Equal(tt.today) {
+			t.Errorf("lúc %v: Today = %v, muốn %v", tt.now, got, tt.today)
+		}
+		if got, _ := ParseDate("hôm qua"); !got.Equal(tt.yesterday) {
+			t.Errorf("lúc %v: hôm qua = %v, muốn %v", tt.now, got, tt.yesterday)
+		}
+		if got := DaysSince(at(2025, 3, 5, 23, 0)); got != tt.since {
+			t.Errorf("lúc %v: DaysSince = %d, muốn %d", tt.now, got, tt.since)
+		}
+		// tin đăng lúc 23h hôm trước theo giờ UTC vẫn là cùng ngày theo giờ Việt Nam
+		if got := DaysSince(time.Date(2025, 3, 4, 23, 0, 0, 0, time.UTC)); got != tt.since {
+			t.Errorf("lúc %v: DaysSince(UTC) = %d, muốn %d", tt.now, got, tt.since)
+		}
+		restore()
+	}
+
+	defer SetClock(func() time.Time { return testNow })()
+	if got := DaysSince(at(2025, 3, 10, 0, 0)); got != -5 {
+		t.Errorf("ngày trong tương lai: DaysSince = %d, muốn -5", got)
+	}
+}
+
+func TestFindDates(t *testing.T) {
+	got := FindDates("Nhận hồ sơ từ 01/03/2025 đến 31/02/2025, thi ngày 5 tháng 4 năm 2025 hoặc 10 - 04 - 2025")
+	want := []time.Time{at(2025, 3, 1, 0, 0), at(2025, 4, 5, 0, 0), at(2025, 4, 10, 0, 0)}
+	if len(got) != len(want) {
+		t.Fatalf("FindDates = %v, muốn %v", got, want)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("FindDates[%d] = %v, muốn %v", i, got[i], want[i])
+		}
+	}
+}
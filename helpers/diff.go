@@ -0,0 +1,71 @@
+package helpers
+
+// DiffOp là loại của một dòng trong kết quả so sánh.
+type DiffOp int
+
+const (
+	// DiffEqual là dòng có ở cả hai bản
+	DiffEqual DiffOp = iota
+	// DiffDelete là dòng chỉ có ở bản cũ
+	DiffDelete
+	// DiffInsert là dòng chỉ có ở bản mới
+	DiffInsert
+)
+
+// DiffLine là một dòng trong kết quả so sánh.
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// DiffLines so sánh hai bản theo từng dòng (dãy con chung dài nhất), dòng xóa đứng trước dòng thêm.
+func DiffLines(a, b []string) []DiffLine {
+	// bỏ phần đầu, phần cuối giống nhau để bảng quy hoạch động nhỏ lại
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] là độ dài dãy con chung dài nhất của midA[i:] và midB[j:]
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	out := make([]DiffLine, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		out = append(out, DiffLine{DiffEqual, line})
+	}
+	i, j := 0, 0
+	for i < len(midA) || j < len(midB) {
+		switch {
+		case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+			out = append(out, DiffLine{DiffEqual, midA[i]})
+			i, j = i+1, j+1
+		case j == len(midB) || (i < len(midA) && lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, DiffLine{DiffDelete, midA[i]})
+			i++
+		default:
+			out = append(out, DiffLine{DiffInsert, midB[j]})
+			j++
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		out = append(out, DiffLine{DiffEqual, line})
+	}
+	return out
+}
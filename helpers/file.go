@@ -0,0 +1,28 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic ghi data ra path qua một file tạm cùng thư mục rồi đổi tên, để chương trình
+// đang đọc path (textfile collector, trình đọc tin, lịch) không đọc phải file ghi dở.
+// File mới có quyền 0644 thay vì 0600 của file tạm.
+func WriteFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
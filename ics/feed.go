@@ -0,0 +1,33 @@
+package ics
+
+import (
+	"fmt"
+	"time"
+	"webcrawler/config"
+	"webcrawler/helpers"
+)
+
+// FeedCalendar tạo lịch gồm mọi tin được lưu từ since có hạn nộp hoặc ngày thi.
+func FeedCalendar(since time.Time) ([]byte, error) {
+	articles, err := config.ListArticles(since)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	for _, a := range articles {
+		events = append(events, ArticleEvents(a.URL, a.Title, a.Recruitment)...)
+	}
+	return Calendar("Thông báo tuyển dụng", events), nil
+}
+
+// WriteFeed ghi lịch tổng hợp ra file path (ghi file tạm rồi đổi tên để không đọc phải file dở).
+func WriteFeed(path string, since time.Time) error {
+	data, err := FeedCalendar(since)
+	if err != nil {
+		return err
+	}
+	if err := helpers.WriteFileAtomic(path, data); err != nil {
+		return fmt.Errorf("lỗi ghi file lịch: %w", err)
+	}
+	return nil
+}
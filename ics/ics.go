@@ -0,0 +1,126 @@
+// Package ics tạo lịch iCalendar (RFC 5545) cho hạn nộp hồ sơ và ngày thi.
+package ics
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"webcrawler/extract"
+	"webcrawler/helpers"
+)
+
+// Event là một sự kiện cả ngày trong lịch.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	URL         string
+	Date        time.Time
+}
+
+// ArticleEvents tạo sự kiện hạn nộp hồ sơ và các ngày thi của một tin.
+func ArticleEvents(url, title string, r extract.Recruitment) []Event {
+	var events []Event
+	id := uidPrefix(url)
+	if r.Deadline != nil {
+		events = append(events, Event{
+			UID:         id + "-deadline@webcrawler",
+			Summary:     "Hạn nộp hồ sơ: " + title,
+			Description: description(url, r),
+			URL:         url,
+			Date:        *r.Deadline,
+		})
+	}
+	for _, d := range r.ExamDates {
+		events = append(events, Event{
+			UID:         id + "-exam-" + d.Format("20060102") + "@webcrawler",
+			Summary:     "Thi tuyển: " + title,
+			Description: description(url, r),
+			URL:         url,
+			Date:        d,
+		})
+	}
+	return events
+}
+
+// Calendar render các sự kiện thành nội dung file .ics.
+func Calendar(name string, events []Event) []byte {
+	var b strings.Builder
+	stamp := helpers.Now().UTC().Format("20060102T150405Z")
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:-//webcrawler//Thong bao tuyen dung//VI")
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	writeLine(&b, "X-WR-CALNAME:"+escape(name))
+	writeLine(&b, "X-WR-TIMEZONE:Asia/Ho_Chi_Minh")
+	for _, e := range events {
+		day := e.Date.In(helpers.Location)
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+e.UID)
+		writeLine(&b, "DTSTAMP:"+stamp)
+		writeLine(&b, "DTSTART;VALUE=DATE:"+day.Format("20060102"))
+		writeLine(&b, "DTEND;VALUE=DATE:"+day.AddDate(0, 0, 1).Format("20060102"))
+		writeLine(&b, "SUMMARY:"+escape(e.Summary))
+		if e.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+escape(e.Description))
+		}
+		if e.URL != "" {
+			writeLine(&b, "URL:"+e.URL)
+		}
+		writeLine(&b, "TRANSP:TRANSPARENT")
+		writeLine(&b, "END:VEVENT")
+	}
+	writeLine(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+func description(url string, r extract.Recruitment) string {
+	var parts []string
+	if r.Organization != "" {
+		parts = append(parts, "Đơn vị: "+r.Organization)
+	}
+	if r.Positions > 0 {
+		parts = append(parts, fmt.Sprintf("Chỉ tiêu: %d", r.Positions))
+	}
+	if r.Deadline != nil {
+		parts = append(parts, "Hạn nộp hồ sơ: "+r.Deadline.Format("02/01/2006"))
+	}
+	if len(r.ExamDates) > 0 {
+		parts = append(parts, "Thời gian thi: "+extract.FormatDates(r.ExamDates))
+	}
+	if r.Contact != "" {
+		parts = append(parts, "Liên hệ: "+r.Contact)
+	}
+	parts = append(parts, url)
+	return strings.Join(parts, "\n")
+}
+
+func uidPrefix(url string) string {
+	sum := sha1.Sum([]byte(url))
+	return hex.EncodeToString(sum[:8])
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// writeLine ghi một dòng, gập dòng dài hơn 75 byte mà không cắt giữa ký tự UTF-8.
+func writeLine(b *strings.Builder, line string) {
+	const limit = 75
+	n := 0
+	for _, r := range line {
+		size := len(string(r))
+		if n+size > limit {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	b.WriteString("\r\n")
+}
@@ -1,5 +0,0 @@
-CREATE TABLE IF NOT EXISTS sent_links (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    url VARCHAR(255),
-    sent_at DATETIME DEFAULT CURRENT_TIMESTAMP
-);
\ No newline at end of file
@@ -0,0 +1,55 @@
+// Package logging cấu hình log/slog và gắn logger có sẵn thuộc tính (run_id, site, url, stage) vào context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Tên các thuộc tính dùng chung để grep và gom log.
+const (
+	KeyRunID = "run_id"
+	KeySite  = "site"
+	KeyURL   = "url"
+	KeyStage = "stage"
+)
+
+// Setup đặt logger mặc định ghi ra w theo format (text|json) và level (debug|info|warn|error).
+// Log qua gói log chuẩn cũng đi qua logger này.
+func Setup(w io.Writer, format, level string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("LOG_LEVEL không hợp lệ: %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "text", "":
+		h = slog.NewTextHandler(w, opts)
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("LOG_FORMAT không hợp lệ: %q (text|json)", format)
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
+
+type ctxKey struct{}
+
+// From trả về logger gắn trong ctx, hoặc logger mặc định.
+func From(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// With trả về ctx mang logger của ctx cộng thêm các thuộc tính args.
+func With(ctx context.Context, args ...any) context.Context {
+	return context.WithValue(ctx, ctxKey{}, From(ctx).With(args...))
+}
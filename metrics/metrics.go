@@ -0,0 +1,268 @@
+// Package metrics gom số liệu của crawler và xuất theo định dạng text của Prometheus,
+// qua HTTP ở chế độ serve hoặc ra file cho textfile collector khi chạy một lần.
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"webcrawler/helpers"
+)
+
+// Các metric của crawler.
+var (
+	FetchDuration = NewHistogram("crawler_fetch_duration_seconds", "Thời gian tải một trang.",
+		[]float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}, "site")
+	HTTPResponses = NewCounter("crawler_http_responses_total", "Số phản hồi HTTP theo mã trạng thái, error là lỗi kết nối.",
+		"site", "code")
+	Items = NewCounter("crawler_items_total", "Số tin theo bước xử lý (found, new, sent, failed, updated, duplicate).",
+		"site", "stage")
+	NotificationFailures = NewCounter("crawler_notification_failures_total", "Số thông báo gửi lỗi theo kênh.",
+		"channel")
+	DBErrors = NewCounter("crawler_db_errors_total", "Số lỗi database theo thao tác.",
+		"operation")
+	LastSuccess = NewGauge("crawler_last_success_timestamp_seconds", "Thời điểm lần crawl thành công gần nhất của site.",
+		"site")
+	RunDuration = NewGauge("crawler_last_run_duration_seconds", "Thời gian lần crawl gần nhất của site.",
+		"site")
+)
+
+var (
+	mu       sync.Mutex
+	registry []metric
+)
+
+type metric interface {
+	write(w io.Writer)
+}
+
+func register(m metric) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry = append(registry, m)
+}
+
+// desc là phần chung của mọi loại metric: tên, mô tả, nhãn và các giá trị theo nhãn.
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string][]string // khóa nối các giá trị nhãn -> giá trị nhãn
+}
+
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s cần %d nhãn, có %d", d.name, len(d.labels), len(values)))
+	}
+	k := strings.Join(values, "\xff")
+	if _, ok := d.series[k]; !ok {
+		d.series[k] = append([]string(nil), values...)
+	}
+	return k
+}
+
+// sortedKeys trả về các khóa theo thứ tự để đầu ra ổn định.
+func (d *desc) sortedKeys() []string {
+	keys := make([]string, 0, len(d.series))
+	for k := range d.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (d *desc) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, helpEscaper.Replace(d.help), d.name, d.kind)
+}
+
+// labelString tạo {a="x",b="y"}, extra là cặp nhãn bổ sung (dùng cho le của histogram).
+func (d *desc) labelString(values []string, extra ...string) string {
+	var pairs []string
+	for i, l := range d.labels {
+		pairs = append(pairs, l+`="`+escape(values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escape(extra[i+1])+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// giá trị nhãn escape \, " và xuống dòng; mô tả (HELP) chỉ escape \ và xuống dòng
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escape(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Counter là bộ đếm chỉ tăng.
+type Counter struct {
+	desc
+	values map[string]float64
+}
+
+// NewCounter tạo và đăng ký một counter với các nhãn labels.
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{name: name, help: help, kind: "counter", labels: labels, series: map[string][]string{}},
+		values: map[string]float64{}}
+	register(c)
+	return c
+}
+
+// Add cộng v vào series có giá trị nhãn values.
+func (c *Counter) Add(v float64, values ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[c.key(values)] += v
+}
+
+// Inc tăng series có giá trị nhãn values thêm 1.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w)
+	for _, k := range c.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelString(c.series[k]), formatFloat(c.values[k]))
+	}
+}
+
+// Gauge là giá trị có thể tăng giảm.
+type Gauge struct {
+	desc
+	values map[string]float64
+}
+
+// NewGauge tạo và đăng ký một gauge với các nhãn labels.
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{desc: desc{name: name, help: help, kind: "gauge", labels: labels, series: map[string][]string{}},
+		values: map[string]float64{}}
+	register(g)
+	return g
+}
+
+// Set đặt giá trị cho series có giá trị nhãn values.
+func (g *Gauge) Set(v float64, values ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[g.key(values)] = v
+}
+
+func (g *Gauge) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w)
+	for _, k := range g.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, g.labelString(g.series[k]), formatFloat(g.values[k]))
+	}
+}
+
+// Histogram đếm quan sát theo các ngưỡng buckets.
+type Histogram struct {
+	desc
+	buckets []float64
+	counts  map[string][]uint64 // số quan sát <= buckets[i]
+	sums    map[string]float64
+	totals  map[string]uint64
+}
+
+// NewHistogram tạo và đăng ký một histogram, buckets phải tăng dần.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{desc: desc{name: name, help: help, kind: "histogram", labels: labels, series: map[string][]string{}},
+		buckets: buckets, counts: map[string][]uint64{}, sums: map[string]float64{}, totals: map[string]uint64{}}
+	register(h)
+	return h
+}
+
+// Observe ghi nhận một quan sát v cho series có giá trị nhãn values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	k := h.key(values)
+	counts, ok := h.counts[k]
+	if !ok {
+		counts = make([]uint64, len(h.buckets))
+		h.counts[k] = counts
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			counts[i]++
+		}
+	}
+	h.sums[k] += v
+	h.totals[k]++
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w)
+	for _, k := range h.sortedKeys() {
+		values := h.series[k]
+		for i, b := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(values, "le", formatFloat(b)), h.counts[k][i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(values, "le", "+Inf"), h.totals[k])
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelString(values), formatFloat(h.sums[k]))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelString(values), h.totals[k])
+	}
+}
+
+// WriteText ghi mọi metric theo định dạng text của Prometheus.
+func WriteText(w io.Writer) error {
+	mu.Lock()
+	metrics := append([]metric(nil), registry...)
+	mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler phục vụ GET /metrics.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteText(w)
+	})
+}
+
+// WriteFile ghi metric ra path cho textfile collector của node_exporter.
+// Ghi vào file tạm rồi đổi tên để collector không đọc phải file ghi dở.
+func WriteFile(path string) error {
+	var buf bytes.Buffer
+	if err := WriteText(&buf); err != nil {
+		return err
+	}
+	return helpers.WriteFileAtomic(path, buf.Bytes())
+}
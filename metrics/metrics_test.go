@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func output(m metric) string {
+	var b strings.Builder
+	m.write(&b)
+	return b.String()
+}
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram("test_fetch_seconds", "Thời gian tải.", []float64{0.5, 1, 2.5}, "site")
+	for _, v := range []float64{0.2, 0.5, 0.7, 3} {
+		h.Observe(v, "hvtp")
+	}
+	h.Observe(1, "bvhh")
+
+	want := `# HELP test_fetch_seconds Thời gian tải.
+# TYPE test_fetch_seconds histogram
+test_fetch_seconds_bucket{site="bvhh",le="0.5"} 0
+test_fetch_seconds_bucket{site="bvhh",le="1"} 1
+test_fetch_seconds_bucket{site="bvhh",le="2.5"} 1
+test_fetch_seconds_bucket{site="bvhh",le="+Inf"} 1
+test_fetch_seconds_sum{site="bvhh"} 1
+test_fetch_seconds_count{site="bvhh"} 1
+test_fetch_seconds_bucket{site="hvtp",le="0.5"} 2
+test_fetch_seconds_bucket{site="hvtp",le="1"} 3
+test_fetch_seconds_bucket{site="hvtp",le="2.5"} 3
+test_fetch_seconds_bucket{site="hvtp",le="+Inf"} 4
+test_fetch_seconds_sum{site="hvtp"} 4.4
+test_fetch_seconds_count{site="hvtp"} 4
+`
+	if got := output(h); got != want {
+		t.Errorf("histogram:\n%s\nmuốn:\n%s", got, want)
+	}
+}
+
+func TestCounterAndGauge(t *testing.T) {
+	c := NewCounter("test_items_total", "Số tin.", "site", "stage")
+	c.Inc("hvtp", "found")
+	c.Add(2, "hvtp", "found")
+	c.Inc("hvtp", "sent")
+	want := `# HELP test_items_total Số tin.
+# TYPE test_items_total counter
+test_items_total{site="hvtp",stage="found"} 3
+test_items_total{site="hvtp",stage="sent"} 1
+`
+	if got := output(c); got != want {
+		t.Errorf("counter:\n%s\nmuốn:\n%s", got, want)
+	}
+
+	g := NewGauge("test_last_success", "Lần chạy cuối.")
+	g.Set(1741160000)
+	g.Set(1741163600)
+	want = `# HELP test_last_success Lần chạy cuối.
+# TYPE test_last_success gauge
+test_last_success 1.7411636e+09
+`
+	if got := output(g); got != want {
+		t.Errorf("gauge:\n%s\nmuốn:\n%s", got, want)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	c := NewCounter("test_escape_total", "Mô tả có \\ và\nxuống dòng, \"ngoặc\" giữ nguyên.", "url")
+	c.Inc(`C:\tmp "a"` + "\nb")
+	want := `# HELP test_escape_total Mô tả có \\ và\nxuống dòng, "ngoặc" giữ nguyên.
+# TYPE test_escape_total counter
+test_escape_total{url="C:\\tmp \"a\"\nb"} 1
+`
+	if got := output(c); got != want {
+		t.Errorf("escape:\n%s\nmuốn:\n%s", got, want)
+	}
+}
+
+func TestLabelCount(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("thiếu nhãn phải panic")
+		}
+	}()
+	NewCounter("test_labels_total", "Nhãn.", "site", "stage").Inc("hvtp")
+}
+
+func TestWriteFile(t *testing.T) {
+	Items.Inc("test_site", "found")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "crawler.prom")
+	if err := WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `crawler_items_total{site="test_site",stage="found"} 1`) {
+		t.Errorf("thiếu metric trong file:\n%s", data)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0o644 {
+		t.Errorf("quyền file = %v, muốn 0644", fi.Mode().Perm())
+	}
+	// không còn file tạm trong thư mục của collector
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("thư mục có %d file, muốn 1", len(entries))
+	}
+}
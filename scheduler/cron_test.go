@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+var ict = time.FixedZone("ICT", 7*60*60)
+
+func at(y int, m time.Month, d, hour, min int) time.Time {
+	return time.Date(y, m, d, hour, min, 0, 0, ict)
+}
+
+func TestNext(t *testing.T) {
+	// thứ tư 05/03/2025 14:30
+	from := at(2025, 3, 5, 14, 30)
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"0 * * * *", from, at(2025, 3, 5, 15, 0)},
+		{"* * * * *", from, at(2025, 3, 5, 14, 31)},
+		{"30 14 * * *", from, at(2025, 3, 6, 14, 30)}, // đúng lúc khớp thì lấy lần sau
+
+		// khoảng, bước, danh sách
+		{"5-10 9 * * *", from, at(2025, 3, 6, 9, 5)},
+		{"*/15 * * * *", from, at(2025, 3, 5, 14, 45)},
+		{"0 8-18/4 * * *", from, at(2025, 3, 5, 16, 0)},
+		{"10/20 * * * *", from, at(2025, 3, 5, 14, 50)},
+		{"0 8,12,17 * * *", from, at(2025, 3, 5, 17, 0)},
+		{"0,45 1-2,20 * * *", from, at(2025, 3, 5, 20, 0)},
+
+		// thứ, 7 cũng là chủ nhật
+		{"0 9 * * 1", from, at(2025, 3, 10, 9, 0)},
+		{"0 9 * * 0", from, at(2025, 3, 9, 9, 0)},
+		{"0 9 * * 7", from, at(2025, 3, 9, 9, 0)},
+		{"0 9 * * 1-5", at(2025, 3, 7, 10, 0), at(2025, 3, 10, 9, 0)},
+
+		// cả ngày và thứ bị giới hạn thì khớp một trong hai
+		{"0 9 13 * 5", from, at(2025, 3, 7, 9, 0)},
+		{"0 9 13 * 5", at(2025, 3, 8, 0, 0), at(2025, 3, 13, 9, 0)},
+		// một trong hai là * (kể cả */n) thì phải khớp cả hai
+		{"0 9 13 * *", from, at(2025, 3, 13, 9, 0)},
+		{"0 9 */10 * 5", from, at(2025, 3, 21, 9, 0)},
+
+		// sang tháng, sang năm, tháng thiếu ngày
+		{"0 0 1 * *", from, at(2025, 4, 1, 0, 0)},
+		{"0 0 31 * *", at(2025, 4, 1, 0, 0), at(2025, 5, 31, 0, 0)},
+		{"0 0 1 1 *", from, at(2026, 1, 1, 0, 0)},
+		{"59 23 31 12 *", at(2025, 12, 31, 23, 59), at(2026, 12, 31, 23, 59)},
+		{"0 0 29 2 *", from, at(2028, 2, 29, 0, 0)},
+		{"0 12 * 6-8 *", from, at(2025, 6, 1, 12, 0)},
+
+		// mô tả
+		{"@hourly", from, at(2025, 3, 5, 15, 0)},
+		{"@daily", from, at(2025, 3, 6, 0, 0)},
+		{"@weekly", from, at(2025, 3, 9, 0, 0)},
+		{"@monthly", from, at(2025, 4, 1, 0, 0)},
+		{"@yearly", from, at(2026, 1, 1, 0, 0)},
+		{"@every 90m", from, at(2025, 3, 5, 16, 0)},
+
+		// không bao giờ khớp
+		{"0 0 30 2 *", from, time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec, ict)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q từ %v: Next = %v, muốn %v", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+// Giờ trong biểu thức tính theo múi giờ của lịch, không theo múi giờ của thời điểm truyền vào hay của máy.
+func TestNextLocation(t *testing.T) {
+	// 01:30 UTC là 08:30 giờ Việt Nam
+	from := time.Date(2025, 3, 5, 1, 30, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		loc  *time.Location
+		want time.Time
+	}{
+		{"0 9 * * *", ict, time.Date(2025, 3, 5, 2, 0, 0, 0, time.UTC)},
+		{"0 9 * * *", time.UTC, time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=UTC 0 9 * * *", ict, time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC)},
+		// 0h ngày 06/03 giờ Việt Nam vẫn là ngày 05/03 theo UTC
+		{"0 0 6 * *", ict, time.Date(2025, 3, 5, 17, 0, 0, 0, time.UTC)},
+		// múi giờ lệch nửa tiếng: 01:30 UTC là 07:00 IST, 09:00 IST là 03:30 UTC
+		{"0 9 * * *", time.FixedZone("IST", 5*60*60+30*60), time.Date(2025, 3, 5, 3, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec, tt.loc)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q theo %v: Next = %v, muốn %v", tt.spec, tt.loc, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1-x * * * *",
+		"a * * * *",
+		",5 * * * *",
+		"@fortnightly",
+		"@every 0s",
+		"@every 500ms",
+		"@every abc",
+		"CRON_TZ=Nowhere/City 0 * * * *",
+		"CRON_TZ=UTC",
+	} {
+		if _, err := Parse(spec, ict); err == nil {
+			t.Errorf("Parse(%q) không báo lỗi", spec)
+		}
+	}
+}
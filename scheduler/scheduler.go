@@ -0,0 +1,177 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status là trạng thái của một job để hiển thị.
+type Status struct {
+	Name         string    `json:"name"`
+	Spec         string    `json:"spec"`
+	Running      bool      `json:"running"`
+	NextRun      time.Time `json:"next_run"`
+	LastRun      time.Time `json:"last_run,omitempty"`
+	LastDuration string    `json:"last_duration,omitempty"`
+	LastError    string    `json:"last_error,omitempty"`
+}
+
+type job struct {
+	name     string
+	spec     string
+	schedule Schedule
+	run      func(ctx context.Context) error
+
+	mu           sync.Mutex
+	running      bool
+	next         time.Time
+	last         time.Time
+	lastDuration time.Duration
+	lastErr      error
+}
+
+// Scheduler chạy mỗi job theo lịch riêng, không chạy chồng hai lần cùng một job.
+type Scheduler struct {
+	jitter time.Duration
+	loc    *time.Location
+	now    func() time.Time
+
+	mu   sync.Mutex
+	jobs []*job
+	wg   sync.WaitGroup
+}
+
+// New tạo Scheduler tính lịch theo múi giờ loc, mỗi lần chạy được lùi ngẫu nhiên tối đa jitter.
+func New(jitter time.Duration, loc *time.Location) *Scheduler {
+	return &Scheduler{jitter: jitter, loc: loc, now: time.Now}
+}
+
+// Add đăng ký job name chạy theo biểu thức spec.
+func (s *Scheduler) Add(name, spec string, run func(ctx context.Context) error) error {
+	schedule, err := Parse(spec, s.loc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &job{name: name, spec: spec, schedule: schedule, run: run})
+	return nil
+}
+
+// Run chạy các job tới khi ctx bị hủy, rồi đợi các lần chạy dở kết thúc.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	jobs := append([]*job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	var loops sync.WaitGroup
+	for _, j := range jobs {
+		loops.Add(1)
+		go func(j *job) {
+			defer loops.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+	loops.Wait()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	for {
+		next := j.schedule.Next(s.now())
+		if next.IsZero() {
+			slog.Warn("job không còn lịch chạy", "job", j.name)
+			return
+		}
+		if s.jitter > 0 {
+			next = next.Add(rand.N(s.jitter))
+		}
+		j.mu.Lock()
+		j.next = next
+		j.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.start(ctx, j)
+	}
+}
+
+// start chạy job trong goroutine riêng, bỏ qua nếu lần chạy trước chưa xong.
+func (s *Scheduler) start(ctx context.Context, j *job) bool {
+	j.mu.Lock()
+	if j.running {
+		j.mu.Unlock()
+		slog.Info("lần chạy trước chưa xong, bỏ qua", "job", j.name)
+		return false
+	}
+	j.running = true
+	j.last = s.now()
+	j.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		started := time.Now()
+		err := j.run(ctx)
+		if err != nil {
+			slog.Error("job lỗi", "job", j.name, "error", err)
+		}
+		j.mu.Lock()
+		j.running = false
+		j.lastDuration = time.Since(started)
+		j.lastErr = err
+		j.mu.Unlock()
+	}()
+	return true
+}
+
+// RunNow chạy ngay job name (nếu không đang chạy), trả về false nếu không chạy được.
+func (s *Scheduler) RunNow(ctx context.Context, name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.name == name {
+			return s.start(ctx, j)
+		}
+	}
+	return false
+}
+
+// Status trả về trạng thái các job, sắp theo tên.
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	jobs := append([]*job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	out := make([]Status, 0, len(jobs))
+	for _, j := range jobs {
+		j.mu.Lock()
+		st := Status{
+			Name:    j.name,
+			Spec:    j.spec,
+			Running: j.running,
+			NextRun: j.next,
+			LastRun: j.last,
+		}
+		if !j.last.IsZero() && !j.running {
+			st.LastDuration = j.lastDuration.Round(time.Millisecond).String()
+		}
+		if j.lastErr != nil {
+			st.LastError = j.lastErr.Error()
+		}
+		j.mu.Unlock()
+		out = append(out, st)
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].Name < out[b].Name })
+	return out
+}
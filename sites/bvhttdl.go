@@ -2,15 +2,12 @@ package sites
 
 import (
 	"bytes"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"log"
-	"net/http"
+	"log/slog"
 	"regexp"
-	"sync"
-	"webcrawler/config"
 	"webcrawler/helpers"
+	"webcrawler/logging"
 
 	"github.com/PuerkitoBio/goquery"
 	"golang.org/x/net/html"
@@ -21,80 +18,49 @@ type File struct {
 	FileUrl  string `json:"FileUrl"`
 }
 
-const MAX_BVHTTDL_DAYS = 90
-
-func GetBvhttdlNews() {
-	baseURL := "https://bvhttdl.gov.vn/"
-	url := baseURL + "van-ban-quan-ly.htm?keyword=tuyển&nhom=0&coquan=0&theloai=28&linhvuc=0&year=0"
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	resp, err := http.Get(url)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer resp.Body.Close()
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		log.Fatalf("Lỗi khi phân tích HTML: %v", err)
-	}
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, 5)
-	doc.Find(".table-data > tbody > tr").Each(func(i int, s *goquery.Selection) {
-		title := s.Find("td:nth-child(2)").Text()
-		href, exists := s.Find("td:nth-child(2) a").Attr("href")
-		if exists {
-			date := s.Find("td:nth-child(4)").Text()
-			diff, err := helpers.DiffDateToday(date)
-			if err != nil {
-				log.Fatalln(err)
-			}
-			if diff <= MAX_BVHTTDL_DAYS {
-				detailURL := baseURL + href
-				fmt.Printf("Link %d: %s\n", i+1, detailURL)
-				if config.IsLinkSent(detailURL) {
-					log.Printf("✅ Đã gửi: %s\n", detailURL)
-					return
+var bvhttdlSite = &Site{
+	Name:       "bvhttdl",
+	BaseURL:    "https://bvhttdl.gov.vn/",
+	ListPath:   "van-ban-quan-ly.htm?keyword=tuyển&nhom=0&coquan=0&theloai=28&linhvuc=0&year=0",
+	MaxAgeDays: 90,
+	Undated:    UndatedSend,
+	// Tam thoi khong crawl
+	Disabled: true,
+	ParseList: func(s *Site, doc *goquery.Document) []Article {
+		var items []Article
+		doc.Find(".table-data > tbody > tr").Each(func(i int, sel *goquery.Selection) {
+			href, exists := sel.Find("td:nth-child(2) a").Attr("href")
+			if exists {
+				date := sel.Find("td:nth-child(4)").Text()
+				published, err := helpers.ParseDate(date)
+				if err != nil {
+					slog.Warn("không đọc được ngày đăng", logging.KeySite, s.Name, logging.KeyStage, "list", "error", err)
 				}
-				sem <- struct{}{}
-				wg.Add(1)
-				go func(url string) {
-					defer wg.Done()
-					defer func() { <-sem }() // release slot
-					log.Printf("🔍 Đang crawl: %s\n", url)
-					crawlBvhttdlNewsDetail(title, url)
-				}(detailURL)
+				items = append(items, Article{
+					Title:     sel.Find("td:nth-child(2)").Text(),
+					URL:       s.BaseURL + href,
+					Published: published,
+				})
 			}
+		})
+		return items
+	},
+	ParseDetail: func(s *Site, doc *goquery.Document, a *Article) error {
+		contentSelection := doc.Find(".table-detail").First()
+		if contentSelection.Length() == 0 {
+			return fmt.Errorf("%w (.table-detail)", ErrSelectorMissing)
 		}
-	})
-	wg.Wait()
-}
-
-func crawlBvhttdlNewsDetail(title string, detailURL string) {
-	resp, err := http.Get(detailURL)
-	if err != nil {
-		log.Fatalln("Lỗi khi tải trang chi tiết:", err)
-	}
-	defer resp.Body.Close()
-	newsDetail, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		log.Fatalln("Lỗi khi phân tích HTML chi tiết:", err)
-	}
-	contentSelection := newsDetail.Find(".table-detail").First()
-	if contentSelection.Length() == 0 {
-		log.Fatalln("⚠️ Không tìm thấy nội dung")
-	}
-	contentHtml, err := goquery.OuterHtml(contentSelection)
-	if err != nil {
-		log.Fatalln("Lỗi khi lấy HTML content:", err)
-	}
-	fulContentHtmlOut, err := TransformHTML(contentHtml)
-	if err != nil {
-		log.Fatalln("Lỗi khi xử lý đính kèm :", err)
-	}
-	err = config.SendEmail(title, fulContentHtmlOut)
-	if err != nil {
-		log.Fatalln("Lỗi khi gửi email:", err)
-	}
-	config.MarkLinkAsSent(detailURL)
+		contentHtml, err := goquery.OuterHtml(contentSelection)
+		if err != nil {
+			return err
+		}
+		fulContentHtmlOut, err := TransformHTML(contentHtml)
+		if err != nil {
+			return fmt.Errorf("lỗi khi xử lý đính kèm: %w", err)
+		}
+		a.HTML = fulContentHtmlOut
+		return nil
+	},
 }
 
 func TransformHTML(input string) (string, error) {
@@ -0,0 +1,89 @@
+package sites
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/unicode/norm"
+)
+
+// decodeHTML chuyển trang HTML về UTF-8 (dạng dựng sẵn NFC) trước khi phân tích.
+// Bảng mã lấy theo override nếu có, nếu không thì theo BOM, header Content-Type, thẻ meta.
+// Trang khai báo sai (ghi UTF-8 nhưng không phải, hoặc ngược lại) và trang không khai báo
+// được đoán theo nội dung, mặc định là Windows-1258. Trả về tên bảng mã đã dùng.
+func decodeHTML(body []byte, contentType, override string) ([]byte, string, error) {
+	name := strings.ToLower(strings.TrimSpace(override))
+	if name == "" {
+		_, detected, certain := charset.DetermineEncoding(body, contentType)
+		switch {
+		case strings.HasPrefix(detected, "utf-16"):
+			// chỉ nhận biết được qua BOM hoặc header, phần chữ ASCII vẫn là UTF-8 hợp lệ
+			name = detected
+		case utf8.Valid(body):
+			// trang UTF-8 khai báo nhầm thành bảng mã một byte
+			name = "utf-8"
+		case detected == "utf-8" || (detected == "windows-1252" && !certain):
+			// khai báo UTF-8 mà nội dung không hợp lệ, hoặc không khai báo gì
+			name = "windows-1258"
+		default:
+			name = detected
+		}
+	}
+
+	var out []byte
+	switch name {
+	case "utf-8", "utf8":
+		out = body
+	case "tcvn3", "tcvn-3", "vn3", "abc":
+		out, name = decodeTCVN3(body), "tcvn3"
+	default:
+		enc, canonical := charset.Lookup(name)
+		if enc == nil {
+			return nil, "", fmt.Errorf("bảng mã %q không được hỗ trợ", name)
+		}
+		var err error
+		if out, err = enc.NewDecoder().Bytes(body); err != nil {
+			return nil, "", fmt.Errorf("lỗi chuyển mã %s: %w", canonical, err)
+		}
+		name = canonical
+	}
+	// Windows-1258 tách dấu thanh thành ký tự tổ hợp, cần dựng lại để so từ khóa
+	return norm.NFC.Bytes(out), name, nil
+}
+
+// tcvn3 ánh xạ byte 0x80-0xFF của bảng mã TCVN3 (ABC) sang Unicode, 0 là giữ nguyên như Latin-1.
+// Chữ hoa có dấu dùng chung mã với chữ thường (font .VnTimeH), nên được giải mã thành chữ thường.
+var tcvn3 = [128]rune{
+	0xA1 - 0x80: 'Ă', 0xA2 - 0x80: 'Â', 0xA3 - 0x80: 'Ê', 0xA4 - 0x80: 'Ô', 0xA5 - 0x80: 'Ơ', 0xA6 - 0x80: 'Ư', 0xA7 - 0x80: 'Đ',
+	0xA8 - 0x80: 'ă', 0xA9 - 0x80: 'â', 0xAA - 0x80: 'ê', 0xAB - 0x80: 'ô', 0xAC - 0x80: 'ơ', 0xAD - 0x80: 'ư', 0xAE - 0x80: 'đ',
+	0xB5 - 0x80: 'à', 0xB6 - 0x80: 'ả', 0xB7 - 0x80: 'ã', 0xB8 - 0x80: 'á', 0xB9 - 0x80: 'ạ',
+	0xBB - 0x80: 'ằ', 0xBC - 0x80: 'ẳ', 0xBD - 0x80: 'ẵ', 0xBE - 0x80: 'ắ', 0xC6 - 0x80: 'ặ',
+	0xC7 - 0x80: 'ầ', 0xC8 - 0x80: 'ẩ', 0xC9 - 0x80: 'ẫ', 0xCA - 0x80: 'ấ', 0xCB - 0x80: 'ậ',
+	0xCC - 0x80: 'è', 0xCE - 0x80: 'ẻ', 0xCF - 0x80: 'ẽ', 0xD0 - 0x80: 'é', 0xD1 - 0x80: 'ẹ',
+	0xD2 - 0x80: 'ề', 0xD3 - 0x80: 'ể', 0xD4 - 0x80: 'ễ', 0xD5 - 0x80: 'ế', 0xD6 - 0x80: 'ệ',
+	0xD7 - 0x80: 'ì', 0xD8 - 0x80: 'ỉ', 0xDC - 0x80: 'ĩ', 0xDD - 0x80: 'í', 0xDE - 0x80: 'ị',
+	0xDF - 0x80: 'ò', 0xE1 - 0x80: 'ỏ', 0xE2 - 0x80: 'õ', 0xE3 - 0x80: 'ó', 0xE4 - 0x80: 'ọ',
+	0xE5 - 0x80: 'ồ', 0xE6 - 0x80: 'ổ', 0xE7 - 0x80: 'ỗ', 0xE8 - 0x80: 'ố', 0xE9 - 0x80: 'ộ',
+	0xEA - 0x80: 'ờ', 0xEB - 0x80: 'ở', 0xEC - 0x80: 'ỡ', 0xED - 0x80: 'ớ', 0xEE - 0x80: 'ợ',
+	0xEF - 0x80: 'ù', 0xF1 - 0x80: 'ủ', 0xF2 - 0x80: 'ũ', 0xF3 - 0x80: 'ú', 0xF4 - 0x80: 'ụ',
+	0xF5 - 0x80: 'ừ', 0xF6 - 0x80: 'ử', 0xF7 - 0x80: 'ữ', 0xF8 - 0x80: 'ứ', 0xF9 - 0x80: 'ự',
+	0xFA - 0x80: 'ỳ', 0xFB - 0x80: 'ỷ', 0xFC - 0x80: 'ỹ', 0xFD - 0x80: 'ý', 0xFE - 0x80: 'ỵ',
+}
+
+func decodeTCVN3(body []byte) []byte {
+	var b strings.Builder
+	b.Grow(len(body) + len(body)/4)
+	for _, c := range body {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case tcvn3[c-0x80] != 0:
+			b.WriteRune(tcvn3[c-0x80])
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,93 @@
+package sites
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeHTML(t *testing.T) {
+	// "Tuyển dụng" theo từng bảng mã; Windows-1258 ghi dấu thanh bằng ký tự tổ hợp
+	const (
+		cp1258 = "Tuy\xea\xd2n du\xf2ng"
+		tcvn   = "Tuy\xd3n d\xf4ng"
+	)
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		override    string
+		wantCharset string
+		wantErr     bool
+	}{
+		{
+			name:        "UTF-8",
+			body:        "<p>Tuyển dụng</p>",
+			contentType: "text/html; charset=utf-8",
+			wantCharset: "utf-8",
+		},
+		{
+			name:        "Windows-1258 theo thẻ meta",
+			body:        `<meta charset="windows-1258"><p>` + cp1258 + "</p>",
+			contentType: "text/html",
+			wantCharset: "windows-1258",
+		},
+		{
+			name:        "Windows-1258 theo header",
+			body:        "<p>" + cp1258 + "</p>",
+			contentType: "text/html; charset=windows-1258",
+			wantCharset: "windows-1258",
+		},
+		{
+			name:        "khai báo UTF-8 nhưng không phải",
+			body:        "<p>" + cp1258 + "</p>",
+			contentType: "text/html; charset=utf-8",
+			wantCharset: "windows-1258",
+		},
+		{
+			name:        "UTF-8 khai báo nhầm ISO-8859-1",
+			body:        `<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1"><p>Tuyển dụng</p>`,
+			wantCharset: "utf-8",
+		},
+		{
+			name:        "không khai báo",
+			body:        "<p>" + cp1258 + "</p>",
+			wantCharset: "windows-1258",
+		},
+		{
+			name:        "TCVN3 theo cấu hình site",
+			body:        "<p>" + tcvn + "</p>",
+			contentType: "text/html; charset=iso-8859-1",
+			override:    "TCVN3",
+			wantCharset: "tcvn3",
+		},
+		{
+			name:     "bảng mã không hỗ trợ",
+			body:     "<p>Tuyển dụng</p>",
+			override: "klingon",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cs, err := decodeHTML([]byte(tt.body), tt.contentType, tt.override)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("muốn lỗi")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cs != tt.wantCharset {
+				t.Errorf("charset = %q, muốn %q", cs, tt.wantCharset)
+			}
+			if !strings.Contains(string(got), "<p>Tuyển dụng</p>") {
+				t.Errorf("nội dung = %q", got)
+			}
+			if !findKeyword(selection(t, string(got), "p").Text(), []string{"tuyển dụng"}) {
+				t.Error("không khớp từ khóa sau khi chuyển mã")
+			}
+		})
+	}
+}
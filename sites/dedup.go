@@ -0,0 +1,327 @@
+package sites
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"math/bits"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"webcrawler/config"
+	"webcrawler/helpers"
+	"webcrawler/logging"
+)
+
+// mặc định so tin mới với tin đã gửi trong 7 ngày gần nhất
+const defaultDuplicateWindowDays = 7
+
+// ngưỡng coi hai tin là một: simhash nội dung lệch ít bit (hai văn bản không liên quan lệch khoảng 32 bit),
+// hoặc lệch vừa phải kèm tiêu đề gần giống.
+// Tin quá ngắn thì simhash không đáng tin, chỉ so tiêu đề với ngưỡng cao hơn. Tiêu đề chung chung dễ trùng
+// nên tin chỉ giống tin đã gửi về tiêu đề vẫn được gửi, kèm link tin có thể trùng.
+const (
+	dupMinShingles   = 8
+	dupHashDistance  = 6
+	dupLooseDistance = 12
+	dupLooseTitle    = 0.6
+	dupTitleOnly     = 0.9
+	dupShingleWords  = 3
+)
+
+// fingerprint là dấu vết của một tin dùng để so trùng.
+type fingerprint struct {
+	site     string
+	title    map[string]bool // các từ trong tiêu đề
+	hash     uint64          // simhash nội dung
+	shingles int             // số cụm từ dùng để tính hash
+}
+
+func fingerprintOf(a Article) fingerprint {
+	fp := fingerprint{site: a.Site, title: map[string]bool{}}
+	for _, w := range words(a.Title) {
+		fp.title[w] = true
+	}
+	fp.hash, fp.shingles = simhash(words(a.Text))
+	return fp
+}
+
+// words tách văn bản thành các từ chữ thường, bỏ dấu câu.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// simhash tính simhash 64 bit trên các cụm dupShingleWords từ liên tiếp.
+func simhash(ws []string) (uint64, int) {
+	n := len(ws) - dupShingleWords + 1
+	if n <= 0 {
+		return 0, 0
+	}
+	var weights [64]int
+	for i := 0; i < n; i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(ws[i:i+dupShingleWords], " ")))
+		sum := h.Sum64()
+		for b := range weights {
+			if sum&(1<<b) != 0 {
+				weights[b]++
+			} else {
+				weights[b]--
+			}
+		}
+	}
+	var out uint64
+	for b, w := range weights {
+		if w > 0 {
+			out |= 1 << b
+		}
+	}
+	return out, n
+}
+
+// titleSimilarity là hệ số Jaccard giữa hai tập từ của tiêu đề.
+func titleSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for w := range a {
+		if b[w] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// isDuplicate cho biết hai tin ở hai site khác nhau có phải cùng một tin hay không.
+func isDuplicate(a, b fingerprint) bool {
+	if a.site == b.site {
+		return false
+	}
+	title := titleSimilarity(a.title, b.title)
+	if a.shingles >= dupMinShingles && b.shingles >= dupMinShingles {
+		dist := bits.OnesCount64(a.hash ^ b.hash)
+		return dist <= dupHashDistance || (dist <= dupLooseDistance && title >= dupLooseTitle)
+	}
+	return title >= dupTitleOnly
+}
+
+// titleOnly cho biết hai tin được so chỉ bằng tiêu đề vì một trong hai quá ngắn.
+func titleOnly(a, b fingerprint) bool {
+	return a.shingles < dupMinShingles || b.shingles < dupMinShingles
+}
+
+// groupDuplicates chia các tin thành nhóm tin trùng nhau (bắc cầu), giữ thứ tự xuất hiện.
+func groupDuplicates(fps []fingerprint) [][]int {
+	parent := make([]int, len(fps))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range fps {
+		for j := i + 1; j < len(fps); j++ {
+			if isDuplicate(fps[i], fps[j]) {
+				if ri, rj := find(i), find(j); ri != rj {
+					parent[max(ri, rj)] = min(ri, rj)
+				}
+			}
+		}
+	}
+	index := map[int]int{}
+	var groups [][]int
+	for i := range fps {
+		r := find(i)
+		g, ok := index[r]
+		if !ok {
+			g = len(groups)
+			index[r] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// deliverMu cho các lần chạy song song (lịch khác nhau, POST /run) gửi lần lượt, để tin lần chạy trước
+// vừa gửi đã có trong database khi lần chạy sau so trùng với tin đã gửi.
+var deliverMu sync.Mutex
+
+// pendingArticle là tin đã tải xong, chờ gửi, cùng lần crawl site của nó.
+type pendingArticle struct {
+	Article
+	crawl *siteCrawl
+}
+
+// deliverAll gửi email cho các tin mới của mọi site trong lần chạy. Tin trùng nhau giữa các site
+// được gộp vào một email liệt kê link của mọi nguồn. Tin trùng với tin đã gửi trong
+// DUPLICATE_WINDOW_DAYS ngày chỉ được ghi nhận là đã gửi, trừ tin chỉ trùng tiêu đề. DUPLICATE_WINDOW_DAYS=0 là tắt gộp tin.
+func deliverAll(ctx context.Context, crawls []*siteCrawl, opts Options) {
+	var pending []pendingArticle
+	for _, c := range crawls {
+		for _, a := range c.ready {
+			pending = append(pending, pendingArticle{a, c})
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	days := config.GetEnvInt("DUPLICATE_WINDOW_DAYS", defaultDuplicateWindowDays)
+	fps := make([]fingerprint, len(pending))
+	for i, p := range pending {
+		fps[i] = fingerprintOf(p.Article)
+	}
+	var groups [][]int
+	if days > 0 {
+		groups = groupDuplicates(fps)
+	} else {
+		for i := range pending {
+			groups = append(groups, []int{i})
+		}
+	}
+	deliverMu.Lock()
+	defer deliverMu.Unlock()
+	recent := recentFingerprints(ctx, days)
+
+	for _, g := range groups {
+		members := make([]pendingArticle, len(g))
+		memberFps := make([]fingerprint, len(g))
+		for k, i := range g {
+			members[k], memberFps[k] = pending[i], fps[i]
+		}
+		deliverGroup(ctx, members, memberFps, recent, opts)
+	}
+}
+
+// deliverGroup gửi một nhóm tin trùng thành một email và cập nhật số liệu của các site trong nhóm.
+// Tin nhiều nội dung nhất làm bản chính, các tin còn lại được tính là tin trùng.
+func deliverGroup(ctx context.Context, members []pendingArticle, fps []fingerprint, recent []recentArticle, opts Options) {
+	primary := 0
+	for i, m := range members {
+		if len(m.Text) > len(members[primary].Text) {
+			primary = i
+		}
+	}
+	var dups []Article
+	for i, m := range members {
+		if i != primary {
+			dups = append(dups, m.Article)
+		}
+	}
+	a := members[primary].Article
+	base := logging.From(ctx)
+	ctx = logging.With(ctx, logging.KeySite, a.Site, logging.KeyURL, a.URL)
+	logger := logging.From(ctx)
+	// tin chưa bắt đầu gửi thì để lần chạy sau, tin đang gửi được chạy nốt
+	if ctx.Err() != nil {
+		logger.Info("bỏ qua tin do đang dừng", logging.KeyStage, "deliver")
+		return
+	}
+
+	var similar []recentArticle
+	for _, fp := range fps {
+		for _, r := range recent {
+			if !isDuplicate(fp, r.fp) {
+				continue
+			}
+			if titleOnly(fp, r.fp) {
+				// chỉ giống tiêu đề thì chưa đủ chắc để bỏ tin, gửi kèm link tin đã gửi để người nhận tự xem
+				if !slices.ContainsFunc(similar, func(s recentArticle) bool { return s.url == r.url }) {
+					similar = append(similar, r)
+				}
+				continue
+			}
+			for _, m := range members {
+				// mỗi link bị bỏ qua một dòng log kèm link của tin đã gửi để kiểm tra lại khi gộp nhầm
+				base.Warn("tin trùng với tin đã gửi, không gửi lại", logging.KeyStage, "dedup",
+					logging.KeySite, m.Site, logging.KeyURL, m.URL, "title", m.Title,
+					"sent_site", r.fp.site, "sent_url", r.url, "sent_title", r.title)
+				if !opts.DryRun {
+					config.MarkLinkAsSent(m.URL)
+				}
+				m.crawl.stats.add(func(st *Stats) { st.Duplicates++ })
+			}
+			return
+		}
+	}
+
+	for _, r := range similar {
+		logger.Info("tin có tiêu đề giống tin đã gửi, vẫn gửi kèm link tin đó", logging.KeyStage, "dedup",
+			"sent_site", r.fp.site, "sent_url", r.url, "sent_title", r.title)
+	}
+	if len(dups) > 0 {
+		logger.Info("gộp tin trùng ở nhiều site", logging.KeyStage, "dedup", "duplicates", len(dups))
+	}
+	if err := deliver(ctx, a, dups, similar, opts); err != nil {
+		logger.Error("lỗi khi gửi email", logging.KeyStage, "deliver", "error", err)
+		for _, m := range members {
+			m.crawl.stats.add(func(st *Stats) { st.Failed++ })
+		}
+		return
+	}
+	for i, m := range members {
+		if i == primary {
+			m.crawl.stats.add(func(st *Stats) { st.Sent++ })
+		} else {
+			m.crawl.stats.add(func(st *Stats) { st.Duplicates++ })
+		}
+	}
+}
+
+// recentArticle là tin đã gửi gần đây dùng để so trùng.
+type recentArticle struct {
+	url, title string
+	fp         fingerprint
+}
+
+// recentFingerprints đọc các tin đã gửi trong days ngày gần nhất, không có database thì bỏ qua.
+func recentFingerprints(ctx context.Context, days int) []recentArticle {
+	if days <= 0 || config.DB == nil {
+		return nil
+	}
+	articles, err := config.ListArticles(helpers.Now().AddDate(0, 0, -days))
+	if err != nil {
+		logging.From(ctx).Warn("không đọc được tin đã gửi để so trùng", logging.KeyStage, "dedup", "error", err)
+		return nil
+	}
+	out := make([]recentArticle, len(articles))
+	for i, a := range articles {
+		out[i] = recentArticle{url: a.URL, title: a.Title, fp: fingerprintOf(Article{
+			Site: a.Site, Title: a.Title, Text: helpers.HTMLToText(a.Content),
+		})}
+	}
+	return out
+}
+
+// sourcesHTML liệt kê link của mọi site đăng cùng tin và các tin đã gửi có thể trùng,
+// rỗng nếu không có tin nào.
+func sourcesHTML(a Article, dups []Article, similar []recentArticle) string {
+	var b strings.Builder
+	if len(dups) > 0 {
+		b.WriteString(`<p><b>Cùng tin trên:</b></p><ul>`)
+		for _, d := range append([]Article{a}, dups...) {
+			fmt.Fprintf(&b, `<li>%s: <a href="%s">%s</a></li>`,
+				html.EscapeString(d.Site), html.EscapeString(d.URL), html.EscapeString(d.Title))
+		}
+		b.WriteString(`</ul><hr>`)
+	}
+	if len(similar) > 0 {
+		b.WriteString(`<p><b>Có thể trùng với tin đã gửi:</b></p><ul>`)
+		for _, r := range similar {
+			fmt.Fprintf(&b, `<li>%s: <a href="%s">%s</a></li>`,
+				html.EscapeString(r.fp.site), html.EscapeString(r.url), html.EscapeString(r.title))
+		}
+		b.WriteString(`</ul><hr>`)
+	}
+	return b.String()
+}
@@ -0,0 +1,187 @@
+package sites
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const dedupText = `Học viện Tư pháp thông báo tuyển dụng viên chức năm 2025. Chỉ tiêu 12 người gồm giảng viên
+luật hình sự, luật dân sự và chuyên viên phòng đào tạo. Hồ sơ nộp trực tiếp tại phòng tổ chức cán bộ
+từ ngày 10/03/2025 đến hết ngày 20/03/2025 trong giờ hành chính.`
+
+func TestIsDuplicate(t *testing.T) {
+	base := Article{Site: "hvtp", Title: "Thông báo tuyển dụng viên chức năm 2025", Text: dedupText}
+	tests := []struct {
+		name string
+		a    Article
+		want bool
+	}{
+		{"cùng nội dung, tiêu đề khác", Article{Site: "bvhttdl", Title: "Học viện Tư pháp tuyển 12 viên chức", Text: dedupText}, true},
+		{"nội dung sửa ít, tiêu đề gần giống",
+			Article{Site: "bvhttdl", Title: "Thông báo tuyển dụng viên chức năm 2025 của Học viện Tư pháp",
+				Text: strings.Replace(dedupText, "20/03/2025", "25/03/2025 (gia hạn)", 1)}, true},
+		{"cùng site", Article{Site: "hvtp", Title: base.Title, Text: dedupText}, false},
+		{"tiêu đề giống, nội dung khác",
+			Article{Site: "bvhttdl", Title: base.Title, Text: `Bệnh viện thông báo tuyển dụng điều dưỡng và kỹ thuật viên xét nghiệm,
+				hồ sơ gửi qua bưu điện trước ngày 30/04/2025, chi tiết xem tệp đính kèm bên dưới.`}, false},
+		{"tin ngắn, chỉ so tiêu đề", Article{Site: "bvhttdl", Title: "THÔNG BÁO: tuyển dụng viên chức năm 2025!"}, true},
+		{"tin ngắn, tiêu đề khác", Article{Site: "bvhttdl", Title: "Thông báo tuyển dụng viên chức năm 2024"}, false},
+	}
+	for _, tt := range tests {
+		b := base
+		if tt.a.Text == "" {
+			b.Text = ""
+		}
+		if got := isDuplicate(fingerprintOf(b), fingerprintOf(tt.a)); got != tt.want {
+			t.Errorf("%s: isDuplicate = %v, muốn %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDeliverAllGroupsDuplicates(t *testing.T) {
+	t.Setenv("DUPLICATE_WINDOW_DAYS", "7")
+	hvtp := &siteCrawl{site: &Site{Name: "hvtp"}, ready: []Article{
+		{Site: "hvtp", Title: "Thông báo tuyển dụng viên chức năm 2025", URL: "https://hvtp.test/tb", Text: dedupText, HTML: "<p>hvtp</p>"},
+		{Site: "hvtp", Title: "Lịch thi tuyển", URL: "https://hvtp.test/lich", Text: "Lịch thi", HTML: "<p>lịch</p>"},
+	}}
+	bvh := &siteCrawl{site: &Site{Name: "bvhttdl"}, ready: []Article{
+		{Site: "bvhttdl", Title: "Học viện Tư pháp tuyển 12 viên chức", URL: "https://bvh.test/hvtp", Text: dedupText[:len(dedupText)-20], HTML: "<p>bvh</p>"},
+	}}
+	preview := &Preview{}
+	deliverAll(context.Background(), []*siteCrawl{hvtp, bvh}, Options{DryRun: true, Preview: preview})
+
+	items := preview.Items()
+	if len(items) != 2 {
+		t.Fatalf("số email = %d, muốn 2", len(items))
+	}
+	grouped := items[1]
+	if grouped.URL != "https://hvtp.test/tb" {
+		t.Fatalf("bản chính = %s, muốn tin nhiều nội dung nhất", grouped.URL)
+	}
+	for _, want := range []string{"Cùng tin trên", `href="https://hvtp.test/tb"`, `href="https://bvh.test/hvtp"`} {
+		if !strings.Contains(grouped.HTML, want) {
+			t.Errorf("thiếu %q trong\n%s", want, grouped.HTML)
+		}
+	}
+	if strings.Contains(items[0].HTML, "Cùng tin trên") {
+		t.Errorf("tin không trùng lại có danh sách nguồn:\n%s", items[0].HTML)
+	}
+	if st := hvtp.stats.snapshot(); st.Sent != 2 || st.Duplicates != 0 {
+		t.Errorf("hvtp: %v", st)
+	}
+	if st := bvh.stats.snapshot(); st.Sent != 0 || st.Duplicates != 1 {
+		t.Errorf("bvhttdl: %v", st)
+	}
+}
+
+// Tin trùng với tin đã gửi ở lần chạy trước không được gửi nhưng phải có log kèm cả hai link.
+func TestDeliverGroupLogsRecentDuplicate(t *testing.T) {
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	sent := Article{Site: "hvtp", Title: "Thông báo tuyển dụng viên chức năm 2025", Text: dedupText}
+	recent := []recentArticle{{url: "https://hvtp.test/tb", title: sent.Title, fp: fingerprintOf(sent)}}
+	c := &siteCrawl{site: &Site{Name: "bvhttdl"}}
+	late := Article{Site: "bvhttdl", Title: "Học viện Tư pháp tuyển 12 viên chức", URL: "https://bvh.test/hvtp", Text: dedupText}
+	preview := &Preview{}
+	deliverGroup(context.Background(), []pendingArticle{{late, c}}, []fingerprint{fingerprintOf(late)}, recent,
+		Options{DryRun: true, Preview: preview})
+
+	if n := len(preview.Items()); n != 0 {
+		t.Errorf("tin trùng với tin đã gửi vẫn được gửi (%d email)", n)
+	}
+	if st := c.stats.snapshot(); st.Duplicates != 1 || st.Sent != 0 {
+		t.Errorf("số liệu: %v", st)
+	}
+	out := logs.String()
+	for _, want := range []string{"level=WARN", "url=https://bvh.test/hvtp", "sent_url=https://hvtp.test/tb", "sent_site=hvtp"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("thiếu %q trong log:\n%s", want, out)
+		}
+	}
+}
+
+// Tin ngắn chỉ giống tin đã gửi về tiêu đề thì không bị bỏ, email kèm link tin có thể trùng.
+func TestDeliverGroupShortTitleOnly(t *testing.T) {
+	sent := Article{Site: "hvtp", Title: "Thông báo tuyển dụng viên chức năm 2025"}
+	recent := []recentArticle{{url: "https://hvtp.test/tb", title: sent.Title, fp: fingerprintOf(sent)}}
+	c := &siteCrawl{site: &Site{Name: "bvhttdl"}}
+	short := Article{Site: "bvhttdl", Title: "THÔNG BÁO: tuyển dụng viên chức năm 2025!", URL: "https://bvh.test/tb", Text: "Xem tệp đính kèm"}
+	preview := &Preview{}
+	deliverGroup(context.Background(), []pendingArticle{{short, c}}, []fingerprint{fingerprintOf(short)}, recent,
+		Options{DryRun: true, Preview: preview})
+
+	items := preview.Items()
+	if len(items) != 1 {
+		t.Fatalf("số email = %d, muốn 1", len(items))
+	}
+	for _, want := range []string{"Có thể trùng với tin đã gửi", `href="https://hvtp.test/tb"`} {
+		if !strings.Contains(items[0].HTML, want) {
+			t.Errorf("thiếu %q trong\n%s", want, items[0].HTML)
+		}
+	}
+	if st := c.stats.snapshot(); st.Sent != 1 || st.Duplicates != 0 {
+		t.Errorf("số liệu: %v", st)
+	}
+}
+
+// Hai site cùng lịch đăng cùng một tin trong một lần chạy thì chỉ có một email liệt kê cả hai nguồn.
+func TestRunSameScheduleGroupsDuplicates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/robots.txt":
+			http.NotFound(w, r)
+		case strings.HasSuffix(r.URL.Path, "/list"):
+			fmt.Fprintf(w, `<a href="%s">Thông báo tuyển dụng viên chức năm 2025</a>`, strings.TrimSuffix(r.URL.Path, "list")+"tb")
+		default:
+			fmt.Fprintf(w, `<div class="content">%s</div>`, dedupText)
+		}
+	}))
+	defer srv.Close()
+	withFetcher(t, newFetchScheduler(8, 8, 0))
+	withRobots(t)
+	t.Setenv("DUPLICATE_WINDOW_DAYS", "7")
+
+	newSite := func(name, schedule string) *Site {
+		return &Site{
+			Name: name, BaseURL: srv.URL + "/" + name + "/", ListPath: "list", Schedule: schedule,
+			ParseList: func(s *Site, doc *goquery.Document) []Article {
+				href, _ := doc.Find("a").Attr("href")
+				return []Article{{Title: doc.Find("a").Text(), URL: srv.URL + href}}
+			},
+			ParseDetail: func(s *Site, doc *goquery.Document, a *Article) error {
+				a.HTML, _ = doc.Find(".content").Html()
+				return nil
+			},
+		}
+	}
+	targets := []*Site{newSite("docs", "0 * * * *"), newSite("other", "30 * * * *"), newSite("news", "0 * * * *")}
+	groups := BySchedule(targets)
+	if len(groups) != 2 || len(groups[0]) != 2 || groups[0][1].Name != "news" || groups[1][0].Name != "other" {
+		t.Fatalf("BySchedule chia sai: %v", groups)
+	}
+
+	opts := Options{DryRun: true, Preview: &Preview{}}
+	if _, err := Run(context.Background(), groups[0], opts); err != nil {
+		t.Fatal(err)
+	}
+	items := opts.Preview.Items()
+	if len(items) != 1 {
+		t.Fatalf("số email = %d, muốn 1", len(items))
+	}
+	for _, want := range []string{"Cùng tin trên", srv.URL + "/docs/tb", srv.URL + "/news/tb"} {
+		if !strings.Contains(items[0].HTML, want) {
+			t.Errorf("thiếu %q trong\n%s", want, items[0].HTML)
+		}
+	}
+}
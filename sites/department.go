@@ -1,85 +1,42 @@
 package sites
 
 import (
-	"crypto/tls"
 	"fmt"
+
 	"github.com/PuerkitoBio/goquery"
-	"log"
-	"net/http"
-	"sync"
-	"webcrawler/config"
 )
 
-func GetDepartmentNews() {
-	baseURL := "https://soxaydung.hanoi.gov.vn/"
-	url := baseURL + "vi-vn/tim/ket-qua/bmjDoCDhu58geMOjIGjhu5lp"
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	resp, err := http.Get(url)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer resp.Body.Close()
-
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		log.Fatalf("Lỗi khi phân tích HTML: %v", err)
-	}
-
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, 5)
-	doc.Find(".col-md-10 h4 a").Each(func(i int, s *goquery.Selection) {
-		title := s.Text()
-		href, exists := s.Attr("href")
-		if exists {
-			detailURL := baseURL + href
-			fmt.Printf("Link %d: %s\n", i+1, detailURL)
-			if config.IsLinkSent(detailURL) {
-				log.Printf("✅ Đã gửi: %s\n", detailURL)
-				return
+var departmentSite = &Site{
+	Name:               "department",
+	BaseURL:            "https://soxaydung.hanoi.gov.vn/",
+	ListPath:           "vi-vn/tim/ket-qua/bmjDoCDhu58geMOjIGjhu5lp",
+	Undated:            UndatedSend,
+	DetailDateSelector: ".blog-page .date, .blog-page .time",
+	ParseList: func(s *Site, doc *goquery.Document) []Article {
+		var items []Article
+		doc.Find(".col-md-10 h4 a").Each(func(i int, sel *goquery.Selection) {
+			href, exists := sel.Attr("href")
+			if exists {
+				items = append(items, Article{
+					Title:     sel.Text(),
+					URL:       s.BaseURL + href,
+					Published: dateFromSelection(sel.Closest(".col-md-10").Find(".date, .time, p").First()),
+				})
 			}
-			sem <- struct{}{}
-			wg.Add(1)
-			go func(url string) {
-				defer wg.Done()
-				defer func() { <-sem }() // release slot
-				log.Printf("🔍 Đang crawl: %s\n", url)
-				crawlDepartmentNewsDetail(detailURL, title)
-			}(detailURL)
+		})
+		return items
+	},
+	ParseDetail: func(s *Site, doc *goquery.Document, a *Article) error {
+		contentSelection := doc.Find(".blog-page").First()
+		if contentSelection.Length() == 0 {
+			return fmt.Errorf("%w (.blog-page)", ErrSelectorMissing)
 		}
-	})
-	wg.Wait()
-}
-
-func crawlDepartmentNewsDetail(detailURL string, emailTitle string) {
-	resp, err := http.Get(detailURL)
-	if err != nil {
-		log.Println("Lỗi khi tải trang chi tiết:", err)
-		return
-	}
-	defer resp.Body.Close()
 
-	docDetail, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		log.Println("Lỗi khi phân tích HTML chi tiết:", err)
-		return
-	}
-
-	contentSelection := docDetail.Find(".blog-page").First()
-	if contentSelection.Length() == 0 {
-		log.Println("⚠️ Không tìm thấy nội dung")
-		return
-	}
-
-	contentHtml, err := goquery.OuterHtml(contentSelection)
-
-	if err != nil {
-		log.Println("Lỗi khi lấy HTML content:", err)
-		return
-	}
-
-	err = config.SendEmail(emailTitle, contentHtml)
-	if err != nil {
-		log.Println("Lỗi khi gửi email:", err)
-	}
-	config.MarkLinkAsSent(detailURL)
+		contentHtml, err := goquery.OuterHtml(contentSelection)
+		if err != nil {
+			return err
+		}
+		a.HTML = contentHtml
+		return nil
+	},
 }
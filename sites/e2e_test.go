@@ -0,0 +1,91 @@
+package sites
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"webcrawler/cassette"
+	"webcrawler/helpers"
+)
+
+// TestCrawlReplay chạy trọn quy trình crawl mọi site ở chế độ chạy thử, không có database,
+// với phản hồi phát lại từ testdata/cassettes/synthetic, rồi so các email sẽ gửi với
+// testdata/cassettes/synthetic.golden.json. Cassette này tự dựng từ các trang mẫu trong testdata
+// (robots.txt đều là 404), không ghi từ site thật: test kiểm tra các bước lọc, tải chi tiết, gửi
+// của quy trình chứ không phát hiện được site thật đổi giao diện.
+func TestCrawlReplay(t *testing.T) {
+	prev := client.Transport
+	client.Transport = cassette.New(filepath.Join("testdata", "cassettes", "synthetic"), cassette.Replay, nil)
+	t.Cleanup(func() { client.Transport = prev })
+	t.Cleanup(helpers.SetClock(func() time.Time { return fixtureNow }))
+	withFetcher(t, newFetchScheduler(8, 8, 0))
+	withRobots(t)
+
+	opts := Options{DryRun: true, Preview: &Preview{}}
+	if _, err := Run(context.Background(), registry, opts); err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	if err := opts.Preview.WriteJSON(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join("testdata", "cassettes", "synthetic.golden.json")
+	if *update {
+		if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (chạy go test ./sites -update để tạo)", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("kết quả khác %s, chạy go test ./sites -update nếu thay đổi là đúng\n--- got\n%s", path, got.Bytes())
+	}
+}
+
+// cancelTransport gọi cancel ngay sau khi nhận trang đầu tiên (không tính robots.txt),
+// giả lập tín hiệu dừng giữa lần chạy.
+type cancelTransport struct {
+	next   http.RoundTripper
+	cancel context.CancelFunc
+}
+
+func (t cancelTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Path != "/robots.txt" {
+		defer t.cancel()
+	}
+	return t.next.RoundTrip(req)
+}
+
+// TestCrawlInterrupted kiểm tra bị dừng sau trang danh sách thì không tải, không gửi tin nào
+// và lỗi của site cho biết lần chạy bị dừng.
+func TestCrawlInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	prev := client.Transport
+	client.Transport = cancelTransport{cassette.New(filepath.Join("testdata", "cassettes", "synthetic"), cassette.Replay, nil), cancel}
+	t.Cleanup(func() { client.Transport = prev })
+	t.Cleanup(helpers.SetClock(func() time.Time { return fixtureNow }))
+	withFetcher(t, newFetchScheduler(8, 8, 0))
+	withRobots(t)
+
+	opts := Options{DryRun: true, Preview: &Preview{}}
+	results, err := Run(ctx, registry[:1], opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, muốn context.Canceled", err)
+	}
+	if st := results[0].Stats; st.Pages != 1 || st.DetailOK != 0 || st.Failed != 0 {
+		t.Errorf("stats = %+v, muốn chỉ tải trang danh sách", st)
+	}
+	if n := len(opts.Preview.Items()); n != 0 {
+		t.Errorf("có %d email sẽ gửi, muốn 0", n)
+	}
+}
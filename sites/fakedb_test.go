@@ -0,0 +1,120 @@
+package sites
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"webcrawler/config"
+)
+
+// fakeDB là database giả cho test: mọi câu lệnh được ghi lại cùng tham số, câu SELECT trả kết quả
+// theo hàm rows (nil là không có dòng nào).
+type fakeDB struct {
+	mu      sync.Mutex
+	queries []string
+	args    [][]driver.Value
+	rows    func(query string, args []driver.Value) [][]driver.Value
+}
+
+// withFakeDB thay config.DB bằng database giả trong một test.
+func withFakeDB(t *testing.T, rows func(query string, args []driver.Value) [][]driver.Value) *fakeDB {
+	t.Helper()
+	db := &fakeDB{rows: rows}
+	prev := config.DB
+	config.DB = sql.OpenDB(db)
+	t.Cleanup(func() {
+		config.DB.Close()
+		config.DB = prev
+	})
+	return db
+}
+
+// count trả về số câu lệnh có chứa s.
+func (db *fakeDB) count(s string) int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	n := 0
+	for _, q := range db.queries {
+		if strings.Contains(q, s) {
+			n++
+		}
+	}
+	return n
+}
+
+// argsOf trả về tham số của các câu lệnh có chứa s, theo thứ tự chạy.
+func (db *fakeDB) argsOf(s string) [][]driver.Value {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var out [][]driver.Value
+	for i, q := range db.queries {
+		if strings.Contains(q, s) {
+			out = append(out, db.args[i])
+		}
+	}
+	return out
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("không hỗ trợ transaction") }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	s.db.queries = append(s.db.queries, s.query)
+	s.db.args = append(s.db.args, args)
+	s.db.mu.Unlock()
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	s.db.queries = append(s.db.queries, s.query)
+	s.db.args = append(s.db.args, args)
+	s.db.mu.Unlock()
+	var rows [][]driver.Value
+	if s.db.rows != nil {
+		rows = s.db.rows(s.query, args)
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
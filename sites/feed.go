@@ -0,0 +1,256 @@
+package sites
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"log/slog"
+	neturl "net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"webcrawler/config"
+	"webcrawler/helpers"
+	"webcrawler/logging"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/unicode/norm"
+)
+
+// tên nguồn feed dùng làm tiền tố biến môi trường nên chỉ gồm chữ thường, số và _
+var feedNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// feedSites đọc các nguồn RSS/Atom khai báo trong FEEDS, phân tách bằng dấu phẩy hoặc khoảng trắng.
+// Mỗi nguồn là "tên=url" hoặc chỉ url (tên lấy theo host, vd moj.gov.vn thành moj_gov_vn).
+func feedSites() []*Site {
+	var out []*Site
+	seen := map[string]bool{}
+	for _, s := range registry {
+		seen[s.Name] = true
+	}
+	for _, entry := range strings.FieldsFunc(config.GetEnv("FEEDS", ""), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	}) {
+		name, url, ok := strings.Cut(entry, "=")
+		if !ok {
+			name, url = "", entry
+		}
+		u, err := neturl.Parse(url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			slog.Warn("bỏ qua nguồn feed không hợp lệ", "feed", entry)
+			continue
+		}
+		if name == "" {
+			name = strings.ReplaceAll(strings.ReplaceAll(strings.TrimPrefix(u.Hostname(), "www."), ".", "_"), "-", "_")
+		}
+		name = strings.ToLower(name)
+		if !feedNameRe.MatchString(name) || seen[name] {
+			slog.Warn("bỏ qua nguồn feed trùng tên hoặc tên không hợp lệ", logging.KeySite, name, "feed", url)
+			continue
+		}
+		seen[name] = true
+		out = append(out, newFeedSite(name, url))
+	}
+	return out
+}
+
+// newFeedSite tạo nguồn tin từ feed RSS/Atom. Nội dung tin lấy từ feed, trừ khi đặt
+// <NAME>_CONTENT_SELECTOR để tải trang chi tiết và bóc nội dung theo selector.
+func newFeedSite(name, url string) *Site {
+	return &Site{Name: name, BaseURL: url, Feed: true, Undated: UndatedSend}
+}
+
+// feedDoc nhận cả RSS 2.0, RSS 1.0 (item nằm ngoài channel) và Atom.
+type feedDoc struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem   `xml:"item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Links       []string `xml:"link"` // gồm cả atom:link không có chữ nên lấy link đầu tiên khác rỗng
+	GUID        string   `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+type atomEntry struct {
+	Title     atomText   `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	ID        string     `xml:"id"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Summary   atomText   `xml:"summary"`
+	Content   atomText   `xml:"content"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+// atomText là nội dung Atom kiểu text, html (HTML đã escape) hoặc xhtml (XML lồng bên trong).
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) html() string {
+	switch t.Type {
+	case "html":
+		return t.Text
+	case "xhtml":
+		return t.Inner
+	}
+	if strings.TrimSpace(t.Text) == "" {
+		return ""
+	}
+	return "<p>" + html.EscapeString(strings.TrimSpace(t.Text)) + "</p>"
+}
+
+func (t atomText) plain() string {
+	if t.Type == "html" || t.Type == "xhtml" {
+		return helpers.HTMLToText(t.html())
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+// parseFeed bóc các tin trong feed RSS/Atom, link tương đối được chuyển thành tuyệt đối theo feedURL.
+func parseFeed(data []byte, feedURL string) ([]Article, error) {
+	base, err := neturl.Parse(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	var doc feedDoc
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = charset.NewReaderLabel
+	// nhiều feed dùng thực thể HTML như &nbsp; trong nội dung
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("lỗi khi phân tích feed: %w", err)
+	}
+
+	var items []Article
+	resolve := func(link string) string {
+		link = strings.TrimSpace(link)
+		if link == "" {
+			return ""
+		}
+		u, err := base.Parse(link)
+		if err != nil {
+			return ""
+		}
+		return u.String()
+	}
+	for _, it := range append(doc.Channel.Items, doc.Items...) {
+		link := it.GUID
+		for _, l := range it.Links {
+			if strings.TrimSpace(l) != "" {
+				link = l
+				break
+			}
+		}
+		content := it.Content
+		if strings.TrimSpace(content) == "" {
+			content = it.Description
+		}
+		date := it.PubDate
+		if date == "" {
+			date = it.Date
+		}
+		items = append(items, Article{
+			Title:     norm.NFC.String(strings.TrimSpace(html.UnescapeString(it.Title))),
+			URL:       resolve(link),
+			Published: feedDate(date),
+			HTML:      strings.TrimSpace(content),
+		})
+	}
+	for _, e := range doc.Entries {
+		link := e.ID
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+		content := e.Content.html()
+		if strings.TrimSpace(content) == "" {
+			content = e.Summary.html()
+		}
+		date := e.Published
+		if date == "" {
+			date = e.Updated
+		}
+		items = append(items, Article{
+			Title:     norm.NFC.String(e.Title.plain()),
+			URL:       resolve(link),
+			Published: feedDate(date),
+			HTML:      strings.TrimSpace(content),
+		})
+	}
+	// tin không có link thì không có gì để ghi nhận đã gửi
+	return slices.DeleteFunc(items, func(a Article) bool { return a.URL == "" }), nil
+}
+
+// các định dạng ngày hay gặp: RFC 822 của RSS (kể cả ngày một chữ số), RFC 3339 của Atom
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// feedDate đọc ngày đăng trong feed, zero nếu không đọc được.
+func feedDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range feedDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, helpers.Location); err == nil {
+			return t.In(helpers.Location)
+		}
+	}
+	return time.Time{}
+}
+
+// hasDetailPage cho biết nội dung tin lấy từ trang chi tiết (site thường, hoặc feed có selector nội dung).
+func (s *Site) hasDetailPage() bool {
+	return !s.Feed || s.ContentSelector != ""
+}
+
+// parseContent bóc nội dung trang chi tiết của nguồn feed theo ContentSelector.
+func (s *Site) parseContent(doc *goquery.Document, a *Article) error {
+	sel := doc.Find(s.ContentSelector).First()
+	if sel.Length() == 0 {
+		return fmt.Errorf("%w (%s)", ErrSelectorMissing, s.ContentSelector)
+	}
+	content, err := goquery.OuterHtml(sel)
+	if err != nil {
+		return err
+	}
+	a.HTML = content
+	return nil
+}
+
+// feedContent dùng nội dung đi kèm trong feed, feed chỉ có tiêu đề thì gửi tiêu đề kèm link.
+func feedContent(a *Article) {
+	if strings.TrimSpace(helpers.HTMLToText(a.HTML)) == "" {
+		a.HTML = fmt.Sprintf(`<p><a href="%s">%s</a></p>`, html.EscapeString(a.URL), html.EscapeString(a.Title))
+	}
+}
@@ -0,0 +1,178 @@
+package sites
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"webcrawler/helpers"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const testRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:atom="http://www.w3.org/2005/Atom">
+<channel>
+	<title>Bộ Tư pháp</title>
+	<atom:link href="https://moj.test/rss.xml" rel="self"/>
+	<item>
+		<title>Thông báo tuyển dụng công chức năm 2025</title>
+		<atom:link href="https://moj.test/self" rel="self"/>
+		<link>/tin/tuyen-dung-2025.html</link>
+		<pubDate>Fri, 14 Mar 2025 08:30:00 +0700</pubDate>
+		<description>Tóm tắt</description>
+		<content:encoded><![CDATA[<p>Chỉ tiêu:&nbsp;10 người</p>]]></content:encoded>
+	</item>
+	<item>
+		<title>Lịch tiếp công dân</title>
+		<guid>https://moj.test/tin/lich.html</guid>
+		<dc:date>2025-03-13T09:00:00+07:00</dc:date>
+	</item>
+	<item>
+		<title>Tin không có link</title>
+	</item>
+</channel>
+</rss>`
+
+const testAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Sở Nội vụ</title>
+	<entry>
+		<title type="html">Thi tuyển viên chức &amp;lt;đợt 2&amp;gt;</title>
+		<link rel="alternate" href="https://snv.test/thi-tuyen"/>
+		<id>tag:snv.test,2025:1</id>
+		<updated>2025-03-12T10:00:00Z</updated>
+		<summary type="html">&lt;p&gt;Hạn nộp hồ sơ 30/03/2025&lt;/p&gt;</summary>
+	</entry>
+</feed>`
+
+func TestParseFeed(t *testing.T) {
+	items, err := parseFeed([]byte(testRSS), "https://moj.test/rss.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("số tin = %d, muốn 2: %+v", len(items), items)
+	}
+	if items[0].URL != "https://moj.test/tin/tuyen-dung-2025.html" {
+		t.Errorf("link tương đối = %s", items[0].URL)
+	}
+	if want := time.Date(2025, 3, 14, 8, 30, 0, 0, helpers.Location); !items[0].Published.Equal(want) {
+		t.Errorf("pubDate = %v, muốn %v", items[0].Published, want)
+	}
+	if items[0].HTML != "<p>Chỉ tiêu:&nbsp;10 người</p>" {
+		t.Errorf("content:encoded = %q", items[0].HTML)
+	}
+	if items[1].URL != "https://moj.test/tin/lich.html" || items[1].Published.IsZero() {
+		t.Errorf("tin chỉ có guid và dc:date: %+v", items[1])
+	}
+
+	items, err = parseFeed([]byte(testAtom), "https://snv.test/atom.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("số tin Atom = %d, muốn 1", len(items))
+	}
+	a := items[0]
+	if a.Title != "Thi tuyển viên chức <đợt 2>" || a.URL != "https://snv.test/thi-tuyen" ||
+		a.HTML != "<p>Hạn nộp hồ sơ 30/03/2025</p>" || a.Published.IsZero() {
+		t.Errorf("tin Atom: %+v", a)
+	}
+
+	if _, err := parseFeed([]byte("<html><body>không phải feed"), "https://x.test/"); err == nil {
+		t.Error("muốn lỗi với trang không phải feed")
+	}
+}
+
+func TestFeedSites(t *testing.T) {
+	t.Setenv("FEEDS", "moj=https://moj.test/rss.xml, https://www.snv-hanoi.test/atom.xml ftp://x.test/rss hvtp=https://dup.test/rss")
+	var names []string
+	for _, s := range feedSites() {
+		names = append(names, s.Name+"="+s.ListURL())
+	}
+	want := "moj=https://moj.test/rss.xml snv_hanoi_test=https://www.snv-hanoi.test/atom.xml"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("feedSites = %q, muốn %q", got, want)
+	}
+}
+
+// Từ khóa đặt qua biến môi trường có thể viết hoa, tiêu đề trong feed có thể dùng dấu tổ hợp (NFD).
+func TestKeywordsFromEnv(t *testing.T) {
+	t.Setenv("MOJ_KEYWORDS", "Tuyển dụng, THI TUYỂN")
+	s := newFeedSite("moj", "https://moj.test/rss.xml")
+	s.loadEnv()
+
+	rss := `<rss version="2.0"><channel><item><title>` + norm.NFD.String("Thông báo Tuyển Dụng viên chức") +
+		`</title><link>https://moj.test/1</link></item></channel></rss>`
+	items, err := parseFeed([]byte(rss), "https://moj.test/rss.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || !norm.NFC.IsNormalString(items[0].Title) {
+		t.Fatalf("tiêu đề trong feed chưa chuẩn hóa NFC: %+v", items)
+	}
+
+	tests := map[string]bool{
+		items[0].Title:                        true,
+		"Kế hoạch thi tuyển viên chức":        true,
+		norm.NFD.String("KẾ HOẠCH THI TUYỂN"): true,
+		"Lịch nghỉ lễ":                        false,
+	}
+	for title, want := range tests {
+		if got := findKeyword(title, s.Keywords); got != want {
+			t.Errorf("findKeyword(%q, %q) = %v, muốn %v", title, s.Keywords, got, want)
+		}
+	}
+}
+
+func TestFeedSiteCrawl(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss.xml":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			w.Write([]byte(testRSS))
+		case "/tin/tuyen-dung-2025.html":
+			w.Write([]byte(`<html><body><div class="menu">Menu</div><div class="noi-dung"><p>Hạn nộp hồ sơ: 30/03/2025</p></div></body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	withFetcher(t, newFetchScheduler(8, 8, 0))
+	withRobots(t)
+	t.Cleanup(helpers.SetClock(func() time.Time { return fixtureNow }))
+
+	t.Setenv("MOJ_KEYWORDS", "tuyển dụng, thi tuyển")
+	t.Setenv("MOJ_CONTENT_SELECTOR", ".noi-dung")
+	s := newFeedSite("moj", srv.URL+"/rss.xml")
+	s.loadEnv()
+	preview := &Preview{}
+	st, err := s.Crawl(context.Background(), Options{DryRun: true, Preview: preview})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.ListItems != 2 || st.New != 1 || st.DetailOK != 1 || st.Sent != 1 {
+		t.Errorf("stats = %v", st)
+	}
+	items := preview.Items()
+	if len(items) != 1 {
+		t.Fatalf("số email = %d, muốn 1", len(items))
+	}
+	if !strings.Contains(items[0].HTML, `<div class="noi-dung">`) || strings.Contains(items[0].HTML, "Menu") {
+		t.Errorf("nội dung không theo selector:\n%s", items[0].HTML)
+	}
+
+	// không có selector thì dùng nội dung trong feed
+	s.ContentSelector = ""
+	preview = &Preview{}
+	if _, err := s.Crawl(context.Background(), Options{DryRun: true, Preview: preview}); err != nil {
+		t.Fatal(err)
+	}
+	if items := preview.Items(); len(items) != 1 || !strings.Contains(items[0].HTML, "Chỉ tiêu:&nbsp;10 người") {
+		t.Errorf("nội dung từ feed: %+v", items)
+	}
+}
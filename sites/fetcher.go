@@ -0,0 +1,192 @@
+package sites
+
+import (
+	"context"
+	"sync"
+	"time"
+	"webcrawler/config"
+)
+
+// fetchScheduler giới hạn số request đồng thời của cả tiến trình và của từng host,
+// giữ khoảng cách tối thiểu giữa hai request tới cùng host và chia lượt công bằng
+// giữa các site đang chờ (round-robin) để một site nhiều tin không chiếm hết lượt.
+type fetchScheduler struct {
+	workers  int
+	perHost  int
+	minDelay time.Duration
+
+	mu     sync.Mutex
+	active int
+	hosts  map[string]*hostState
+	queues map[string][]*fetchWaiter // hàng đợi theo site
+	sites  []string                  // thứ tự xoay vòng các site
+	next   int
+	timer  *time.Timer
+}
+
+type hostState struct {
+	active int
+	// delay là khoảng cách riêng của host (Crawl-delay), chỉ có tác dụng khi lớn hơn minDelay
+	delay time.Duration
+	// earliest là thời điểm sớm nhất được bắt đầu request tiếp theo tới host
+	earliest time.Time
+}
+
+type fetchWaiter struct {
+	host    string
+	ready   chan struct{}
+	granted bool
+}
+
+// mặc định: tối đa 8 request cùng lúc, 2 request mỗi host, cách nhau 500ms
+const (
+	defaultFetchWorkers = 8
+	defaultFetchPerHost = 2
+	defaultHostDelay    = 500 * time.Millisecond
+)
+
+var (
+	fetcherOnce sync.Once
+	fetcher     *fetchScheduler
+)
+
+// sharedFetcher trả về bộ điều phối dùng chung, đọc cấu hình lần đầu được dùng
+// (sau khi đã nạp .env): FETCH_WORKERS, FETCH_PER_HOST, FETCH_HOST_DELAY.
+func sharedFetcher() *fetchScheduler {
+	fetcherOnce.Do(func() {
+		fetcher = newFetchScheduler(
+			config.GetEnvInt("FETCH_WORKERS", defaultFetchWorkers),
+			config.GetEnvInt("FETCH_PER_HOST", defaultFetchPerHost),
+			config.GetEnvDuration("FETCH_HOST_DELAY", defaultHostDelay),
+		)
+	})
+	return fetcher
+}
+
+func newFetchScheduler(workers, perHost int, minDelay time.Duration) *fetchScheduler {
+	return &fetchScheduler{
+		workers:  max(workers, 1),
+		perHost:  max(perHost, 1),
+		minDelay: max(minDelay, 0),
+		hosts:    map[string]*hostState{},
+		queues:   map[string][]*fetchWaiter{},
+	}
+}
+
+// acquire chờ tới lượt tải một trang của host cho site, trả về hàm release phải gọi khi xong.
+func (f *fetchScheduler) acquire(ctx context.Context, site, host string) (release func(), err error) {
+	w := &fetchWaiter{host: host, ready: make(chan struct{})}
+	f.mu.Lock()
+	if _, ok := f.queues[site]; !ok {
+		f.sites = append(f.sites, site)
+	}
+	f.queues[site] = append(f.queues[site], w)
+	f.dispatch()
+	f.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return func() { f.release(host) }, nil
+	case <-ctx.Done():
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if w.granted {
+			// vừa được cấp lượt đúng lúc ctx hủy, trả lại lượt
+			f.releaseLocked(host)
+		} else {
+			f.remove(site, w)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (f *fetchScheduler) release(host string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.releaseLocked(host)
+}
+
+func (f *fetchScheduler) releaseLocked(host string) {
+	f.active--
+	f.hosts[host].active--
+	f.dispatch()
+}
+
+// setDelay đặt khoảng cách tối thiểu giữa hai request tới host, ví dụ theo Crawl-delay của robots.txt.
+func (f *fetchScheduler) setDelay(host string, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.host(host).delay = d
+}
+
+func (f *fetchScheduler) remove(site string, w *fetchWaiter) {
+	q := f.queues[site]
+	for i, x := range q {
+		if x == w {
+			f.queues[site] = append(q[:i:i], q[i+1:]...)
+			return
+		}
+	}
+}
+
+func (f *fetchScheduler) host(name string) *hostState {
+	h, ok := f.hosts[name]
+	if !ok {
+		h = &hostState{}
+		f.hosts[name] = h
+	}
+	return h
+}
+
+// dispatch cấp lượt cho các request đang chờ theo vòng các site, gọi khi giữ mu.
+// Request chỉ bị chặn bởi khoảng cách giữa hai lần gọi host thì hẹn giờ dispatch lại.
+func (f *fetchScheduler) dispatch() {
+	now := time.Now()
+	var wake time.Time
+	for f.active < f.workers {
+		granted := false
+		for i := 0; i < len(f.sites); i++ {
+			idx := (f.next + i) % len(f.sites)
+			site := f.sites[idx]
+			q := f.queues[site]
+			if len(q) == 0 {
+				continue
+			}
+			w := q[0]
+			h := f.host(w.host)
+			if h.active >= f.perHost {
+				continue
+			}
+			if now.Before(h.earliest) {
+				if wake.IsZero() || h.earliest.Before(wake) {
+					wake = h.earliest
+				}
+				continue
+			}
+
+			f.queues[site] = q[1:]
+			f.active++
+			h.active++
+			h.earliest = now.Add(max(f.minDelay, h.delay))
+			w.granted = true
+			close(w.ready)
+			f.next = idx + 1
+			granted = true
+			break
+		}
+		if !granted {
+			break
+		}
+	}
+
+	if !wake.IsZero() && f.active < f.workers {
+		if f.timer != nil {
+			f.timer.Stop()
+		}
+		f.timer = time.AfterFunc(time.Until(wake), func() {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.dispatch()
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package sites
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFetchSchedulerLimits(t *testing.T) {
+	f := newFetchScheduler(3, 2, 0)
+	ctx := context.Background()
+
+	var (
+		mu              sync.Mutex
+		active, maxSeen int
+		perHost         = map[string]int{}
+		maxHost         int
+		wg              sync.WaitGroup
+	)
+	for i := 0; i < 12; i++ {
+		host := []string{"a", "b", "c"}[i%3]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := f.acquire(ctx, host, host)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			active++
+			perHost[host]++
+			maxSeen = max(maxSeen, active)
+			maxHost = max(maxHost, perHost[host])
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			active--
+			perHost[host]--
+			mu.Unlock()
+			release()
+		}()
+	}
+	wg.Wait()
+	if maxSeen > 3 {
+		t.Errorf("tối đa %d request đồng thời, giới hạn 3", maxSeen)
+	}
+	if maxHost > 2 {
+		t.Errorf("tối đa %d request đồng thời tới một host, giới hạn 2", maxHost)
+	}
+}
+
+func TestFetchSchedulerHostDelay(t *testing.T) {
+	f := newFetchScheduler(4, 4, 30*time.Millisecond)
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := f.acquire(ctx, "s", "h")
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	if d := time.Since(start); d < 60*time.Millisecond {
+		t.Errorf("3 request tới cùng host mất %s, cần cách nhau ít nhất 30ms", d)
+	}
+}
+
+func TestFetchSchedulerFair(t *testing.T) {
+	f := newFetchScheduler(1, 1, 0)
+	ctx := context.Background()
+
+	// giữ lượt duy nhất để các request xếp hàng
+	hold, _ := f.acquire(ctx, "x", "x")
+
+	var (
+		mu    sync.Mutex
+		order []string
+		wg    sync.WaitGroup
+	)
+	enqueue := func(site string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := f.acquire(ctx, site, site+".host")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, site)
+			mu.Unlock()
+			release()
+		}()
+	}
+	// site a xếp 3 request trước, site b xếp 1 request sau
+	for i := 0; i < 3; i++ {
+		enqueue("a")
+		waitQueued(t, f, "a", i+1)
+	}
+	enqueue("b")
+	waitQueued(t, f, "b", 1)
+
+	hold()
+	wg.Wait()
+	// b không phải chờ a chạy hết
+	if len(order) != 4 || order[3] == "b" {
+		t.Errorf("thứ tự %v, b phải được chen vào giữa các request của a", order)
+	}
+}
+
+func TestFetchSchedulerCancel(t *testing.T) {
+	f := newFetchScheduler(1, 1, 0)
+	hold, _ := f.acquire(context.Background(), "s", "h")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := f.acquire(ctx, "s", "h")
+		done <- err
+	}()
+	waitQueued(t, f, "s", 1)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("err = %v, muốn context.Canceled", err)
+	}
+	hold()
+
+	// lượt đã hủy không được giữ chỗ
+	release, err := f.acquire(context.Background(), "s", "h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
+
+// waitQueued chờ site có n request trong hàng đợi.
+func waitQueued(t *testing.T, f *fetchScheduler, site string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		f.mu.Lock()
+		got := len(f.queues[site])
+		f.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("site %s không có %d request chờ", site, n)
+}
@@ -0,0 +1,45 @@
+package sites
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Tên file mẫu trong thư mục testdata/<site>/ dùng cho test bóc tách.
+const (
+	FixtureList   = "list.html"
+	FixtureDetail = "detail.html"
+)
+
+// RefreshFixtures tải lại trang danh sách và trang chi tiết của tin đầu tiên trên
+// danh sách từ site thật rồi ghi vào dir/<site>/. Sau đó cần chạy
+// go test ./sites -update để cập nhật kết quả mong đợi.
+func (s *Site) RefreshFixtures(ctx context.Context, dir string) error {
+	// trang mẫu giữ nguyên bảng mã gốc để test đi qua cả bước chuyển mã
+	list, contentType, err := s.fetchBody(ctx, s.ListURL())
+	if err != nil {
+		return fmt.Errorf("%s: %w", s.Name, err)
+	}
+	items, err := s.parseList(ctx, s.ListURL(), page{body: list, contentType: contentType})
+	if err != nil {
+		return fmt.Errorf("%s: %w", s.Name, err)
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("%s: trang danh sách không có tin nào", s.Name)
+	}
+	detail, _, err := s.fetchBody(ctx, items[0].URL)
+	if err != nil {
+		return fmt.Errorf("%s: %w", s.Name, err)
+	}
+
+	siteDir := filepath.Join(dir, s.Name)
+	if err := os.MkdirAll(siteDir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(siteDir, FixtureList), list, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(siteDir, FixtureDetail), detail, 0o644)
+}
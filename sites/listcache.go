@@ -0,0 +1,61 @@
+package sites
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"webcrawler/config"
+	"webcrawler/logging"
+)
+
+// fetchList tải trang danh sách của một lần crawl. Khi bật CONDITIONAL_GET (mặc định) và không
+// chạy thử, request gửi kèm ETag/Last-Modified đã lưu; unchanged là true nếu server trả 304 hoặc
+// nội dung trùng hash lần trước. Thông tin đã lưu chỉ dùng khi bộ lọc tin của site (từ khóa, tuổi tin,
+// tin không có ngày) không đổi, để đổi bộ lọc có tác dụng ngay cả khi danh sách không đổi.
+// validator là thông tin cần lưu sau khi crawl xong, URL rỗng nếu không dùng request có điều kiện.
+func (s *Site) fetchList(ctx context.Context, opts Options) (items []Article, validator config.PageValidator, unchanged bool, err error) {
+	url := s.ListURL()
+	var prev config.PageValidator
+	if !opts.DryRun && config.GetEnvBool("CONDITIONAL_GET", true) {
+		if prev, err = config.GetPageValidator(url); err != nil {
+			// thiếu thông tin lần trước thì tải lại toàn bộ như bình thường
+			logging.From(ctx).Warn("không đọc được thông tin trang danh sách", logging.KeyStage, "list", "error", err)
+			prev = config.PageValidator{URL: url}
+		}
+		if prev.FilterHash != s.filterHash() {
+			if prev.BodyHash != "" {
+				logging.From(ctx).Info("bộ lọc tin của site đã đổi, tải lại toàn bộ danh sách", logging.KeyStage, "list")
+			}
+			prev = config.PageValidator{URL: url}
+		}
+	}
+
+	p, err := s.fetch(ctx, url, prev)
+	if err != nil {
+		return nil, validator, false, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	if p.notModified {
+		return nil, validator, true, nil
+	}
+	sum := sha256.Sum256(p.body)
+	hash := hex.EncodeToString(sum[:])
+	if prev.BodyHash == hash {
+		return nil, validator, true, nil
+	}
+	if prev.URL != "" {
+		validator = config.PageValidator{URL: url, ETag: p.etag, LastModified: p.lastModified, BodyHash: hash, FilterHash: s.filterHash()}
+	}
+
+	items, err = s.parseList(ctx, url, p)
+	if err != nil {
+		return nil, validator, false, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	return items, validator, false, nil
+}
+
+// filterHash là hash các thiết lập lọc tin trên trang danh sách của site.
+func (s *Site) filterHash() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%q %d %s", s.Keywords, s.MaxAgeDays, s.Undated)))
+	return hex.EncodeToString(sum[:])
+}
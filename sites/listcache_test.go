@@ -0,0 +1,154 @@
+package sites
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"webcrawler/config"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestFetchConditional(t *testing.T) {
+	const etag = `"v1"`
+	var gotIfNoneMatch, gotIfModifiedSince string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		gotIfNoneMatch, gotIfModifiedSince = r.Header.Get("If-None-Match"), r.Header.Get("If-Modified-Since")
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Sat, 15 Mar 2025 03:00:00 GMT")
+		if gotIfNoneMatch == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("<html></html>"))
+	}))
+	defer srv.Close()
+	withFetcher(t, newFetchScheduler(8, 8, 0))
+	withRobots(t)
+	s := &Site{Name: "test"}
+
+	p, err := s.fetch(context.Background(), srv.URL+"/list", config.PageValidator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.notModified || p.etag != etag || p.lastModified == "" || len(p.body) == 0 {
+		t.Fatalf("lần đầu: %+v", p)
+	}
+	if gotIfNoneMatch != "" || gotIfModifiedSince != "" {
+		t.Errorf("lần đầu không được gửi header điều kiện: %q %q", gotIfNoneMatch, gotIfModifiedSince)
+	}
+
+	p, err = s.fetch(context.Background(), srv.URL+"/list", config.PageValidator{ETag: p.etag, LastModified: p.lastModified})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.notModified || len(p.body) != 0 {
+		t.Errorf("lần hai muốn 304: %+v", p)
+	}
+	if gotIfModifiedSince == "" {
+		t.Error("thiếu If-Modified-Since")
+	}
+
+	// 304 khi không gửi điều kiện là lỗi
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	})
+	if _, err := s.fetch(context.Background(), srv.URL+"/list", config.PageValidator{}); err == nil {
+		t.Error("muốn lỗi khi server trả 304 cho request không điều kiện")
+	}
+}
+
+func TestUnchangedListNoAlert(t *testing.T) {
+	const etag = `"v1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	withFetcher(t, newFetchScheduler(8, 8, 0))
+	withRobots(t)
+	t.Setenv("UPDATE_WINDOW_DAYS", "0")
+	s := &Site{Name: "test", BaseURL: srv.URL + "/", ListPath: "list"}
+	db := withFakeDB(t, func(query string, _ []driver.Value) [][]driver.Value {
+		switch {
+		case strings.Contains(query, "FROM page_cache"):
+			return [][]driver.Value{{etag, nil, "hash", s.filterHash()}}
+		case strings.Contains(query, "FROM site_health"):
+			// nếu bộ đếm bị cập nhật thì đã tới ngưỡng cảnh báo
+			return [][]driver.Value{{int64(defaultSelectorAlertRuns), int64(0)}}
+		}
+		return nil
+	})
+
+	for run := 1; run <= 3; run++ {
+		st, err := s.Crawl(context.Background(), Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !st.Unchanged {
+			t.Fatalf("lần %d: muốn danh sách không đổi, được %v", run, st)
+		}
+	}
+	if n := db.count("site_health"); n != 0 {
+		t.Errorf("lần chạy không đổi vẫn cập nhật site_health %d lần", n)
+	}
+}
+
+// Đổi từ khóa hay tuổi tin thì lần chạy sau phải lọc lại danh sách dù danh sách không đổi.
+func TestFilterChangeRefetchesList(t *testing.T) {
+	const etag = `"v1"`
+	var conditional int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte("<html></html>"))
+	}))
+	defer srv.Close()
+	withFetcher(t, newFetchScheduler(8, 8, 0))
+	withRobots(t)
+	t.Setenv("UPDATE_WINDOW_DAYS", "0")
+	s := &Site{Name: "test", BaseURL: srv.URL + "/", ListPath: "list", Keywords: []string{"tuyển dụng"},
+		ParseList: func(*Site, *goquery.Document) []Article { return nil }}
+	saved := s.filterHash()
+	db := withFakeDB(t, func(query string, _ []driver.Value) [][]driver.Value {
+		if strings.Contains(query, "FROM page_cache") {
+			return [][]driver.Value{{etag, nil, "hash", saved}}
+		}
+		return nil
+	})
+
+	st, err := s.Crawl(context.Background(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Unchanged || conditional != 1 {
+		t.Fatalf("cùng bộ lọc: muốn 304, được %v", st)
+	}
+
+	s.Keywords = []string{"tuyển dụng", "thi tuyển"}
+	st, err = s.Crawl(context.Background(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Unchanged || conditional != 1 {
+		t.Errorf("đổi từ khóa: muốn tải lại danh sách không điều kiện, được %v", st)
+	}
+	args := db.argsOf("INSERT INTO page_cache")
+	if len(args) != 1 || args[0][len(args[0])-1] != s.filterHash() {
+		t.Errorf("thông tin trang lưu lại phải có hash bộ lọc mới: %v", args)
+	}
+}
@@ -0,0 +1,87 @@
+package sites
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Options điều khiển một lần crawl.
+type Options struct {
+	// DryRun chạy đủ các bước crawl, lọc nhưng không gửi email và không ghi database
+	DryRun bool
+	// Preview nhận các email lẽ ra được gửi khi DryRun, có thể nil
+	Preview *Preview
+}
+
+// Notification là một email lẽ ra được gửi trong lần chạy thử.
+type Notification struct {
+	Site        string     `json:"site"`
+	URL         string     `json:"url"`
+	Subject     string     `json:"subject"`
+	Published   *time.Time `json:"published,omitempty"`
+	Attachments []string   `json:"attachments,omitempty"`
+	HTML        string     `json:"html"`
+}
+
+// Preview gom các email của lần chạy thử, an toàn khi dùng từ nhiều goroutine.
+type Preview struct {
+	mu    sync.Mutex
+	items []Notification
+}
+
+// Add ghi nhận email sẽ gửi cho tin a.
+func (p *Preview) Add(a Article, subject, body string, attachments []string) {
+	n := Notification{Site: a.Site, URL: a.URL, Subject: subject, Attachments: attachments, HTML: body}
+	if !a.Published.IsZero() {
+		published := a.Published
+		n.Published = &published
+	}
+	p.mu.Lock()
+	p.items = append(p.items, n)
+	p.mu.Unlock()
+}
+
+// Items trả về các email đã ghi nhận, sắp theo site rồi url.
+func (p *Preview) Items() []Notification {
+	p.mu.Lock()
+	items := append([]Notification(nil), p.items...)
+	p.mu.Unlock()
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Site != items[j].Site {
+			return items[i].Site < items[j].Site
+		}
+		return items[i].URL < items[j].URL
+	})
+	return items
+}
+
+// WriteJSON ghi danh sách email dạng JSON.
+func (p *Preview) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(p.Items())
+}
+
+// WriteHTML ghi trang HTML xem trước toàn bộ email.
+func (p *Preview) WriteHTML(w io.Writer) error {
+	items := p.Items()
+	if _, err := fmt.Fprintf(w, "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>Xem trước %d email</title></head><body>\n", len(items)); err != nil {
+		return err
+	}
+	for _, n := range items {
+		fmt.Fprintf(w, "<section style=\"border:1px solid #ccc;margin:16px 0;padding:12px\">\n<h2>%s</h2>\n", html.EscapeString(n.Subject))
+		fmt.Fprintf(w, "<p><b>%s</b> · <a href=\"%s\">%s</a></p>\n", html.EscapeString(n.Site), html.EscapeString(n.URL), html.EscapeString(n.URL))
+		for _, name := range n.Attachments {
+			fmt.Fprintf(w, "<p>📎 %s</p>\n", html.EscapeString(name))
+		}
+		fmt.Fprintf(w, "%s\n</section>\n", n.HTML)
+	}
+	_, err := fmt.Fprintln(w, "</body></html>")
+	return err
+}
@@ -0,0 +1,69 @@
+package sites
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"webcrawler/config"
+	"webcrawler/helpers"
+	"webcrawler/ics"
+	"webcrawler/logging"
+)
+
+// số ngày trước hạn nộp hồ sơ cần nhắc, ghi đè bằng REMINDER_DAYS
+var defaultReminderDays = []int{7, 1}
+
+func scheduleReminders(a Article) {
+	if a.Recruitment.Deadline == nil {
+		return
+	}
+	days := config.GetEnvInts("REMINDER_DAYS", defaultReminderDays)
+	config.ScheduleReminders(a.URL, *a.Recruitment.Deadline, days, config.Recipients(), helpers.Now())
+}
+
+// SendDueReminders gửi các email nhắc hạn nộp hồ sơ đã đến lịch.
+func SendDueReminders(ctx context.Context) error {
+	reminders, err := config.DueReminders(helpers.Now())
+	if err != nil {
+		return err
+	}
+	for _, r := range reminders {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		deadline := r.Recruitment.Deadline
+		if deadline == nil || helpers.DaysSince(*deadline) > 0 {
+			// hạn đã qua (ví dụ crawler dừng lâu), không nhắc nữa
+			config.MarkReminderSent(r.ID)
+			continue
+		}
+
+		subject := reminderSubject(r.Title, -helpers.DaysSince(*deadline))
+		body := fmt.Sprintf(`<p>Hạn nộp hồ sơ: <b>%s</b></p><p><a href="%s">%s</a></p>%s`,
+			deadline.Format("02/01/2006"), html.EscapeString(r.URL), html.EscapeString(r.Title), r.Recruitment.HTML())
+		recipients := r.Recipients
+		if len(recipients) == 0 {
+			recipients = config.Recipients()
+		}
+		attachment := config.Attachment{
+			Name:        "lich-tuyen-dung.ics",
+			ContentType: "text/calendar; charset=utf-8; method=PUBLISH",
+			Data:        ics.Calendar(r.Title, ics.ArticleEvents(r.URL, r.Title, r.Recruitment)),
+		}
+		if err := config.SendEmailTo(recipients, subject, body, attachment); err != nil {
+			logging.From(ctx).Error("lỗi khi gửi email nhắc hạn", logging.KeyStage, "reminders", logging.KeyURL, r.URL, "error", err)
+			continue
+		}
+		config.MarkReminderSent(r.ID)
+		logging.From(ctx).Info("đã gửi nhắc hạn", logging.KeyStage, "reminders", logging.KeyURL, r.URL, "days_before", r.DaysBefore)
+	}
+	return nil
+}
+
+// reminderSubject là tiêu đề email nhắc hạn khi còn days ngày, 0 là đúng ngày hết hạn.
+func reminderSubject(title string, days int) string {
+	if days == 0 {
+		return "⏰ Hạn cuối hôm nay nộp hồ sơ: " + title
+	}
+	return fmt.Sprintf("⏰ Còn %d ngày hết hạn nộp hồ sơ: %s", days, title)
+}
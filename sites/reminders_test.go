@@ -0,0 +1,19 @@
+package sites
+
+import "testing"
+
+func TestReminderSubject(t *testing.T) {
+	tests := []struct {
+		days int
+		want string
+	}{
+		{7, "⏰ Còn 7 ngày hết hạn nộp hồ sơ: Tuyển viên chức"},
+		{1, "⏰ Còn 1 ngày hết hạn nộp hồ sơ: Tuyển viên chức"},
+		{0, "⏰ Hạn cuối hôm nay nộp hồ sơ: Tuyển viên chức"},
+	}
+	for _, tt := range tests {
+		if got := reminderSubject("Tuyển viên chức", tt.days); got != tt.want {
+			t.Errorf("%d ngày: %q, muốn %q", tt.days, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package sites
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"webcrawler/config"
+	"webcrawler/logging"
+)
+
+// ErrRobotsDisallowed là lỗi khi robots.txt của site không cho crawler tải url.
+var ErrRobotsDisallowed = errors.New("robots.txt không cho phép")
+
+// robots.txt lớn hơn mức này chỉ đọc phần đầu (RFC 9309 yêu cầu tối thiểu 500 KiB)
+const robotsMaxSize = 500 << 10
+
+// mặc định giữ robots.txt một ngày, lỗi tải thì thử lại sau 10 phút
+const (
+	defaultRobotsTTL = 24 * time.Hour
+	robotsRetry      = 10 * time.Minute
+)
+
+// robotsRules là các quy tắc trong robots.txt áp dụng cho crawler.
+type robotsRules struct {
+	rules []robotsRule
+	// delay là Crawl-delay, 0 nếu không khai báo
+	delay time.Duration
+	// disallowAll khi không tải được robots.txt do lỗi server hoặc mạng
+	disallowAll bool
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// parseRobots đọc robots.txt và giữ các nhóm dành cho agent, không có thì dùng nhóm "*".
+func parseRobots(data []byte, agent string) *robotsRules {
+	agent = strings.ToLower(agent)
+	var (
+		own, star    robotsRules
+		hasOwn       bool
+		inOwn, inAll bool
+		agentLines   = true // đang ở dãy dòng User-agent đầu nhóm
+	)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64<<10), robotsMaxSize)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			if !agentLines {
+				inOwn, inAll = false, false
+				agentLines = true
+			}
+			switch ua := strings.ToLower(value); {
+			case ua == "*":
+				inAll = true
+			case ua != "" && robotsProductToken(ua) == agent:
+				inOwn, hasOwn = true, true
+			}
+			continue
+		}
+		agentLines = false
+		if inOwn {
+			own.add(key, value)
+		}
+		if inAll {
+			star.add(key, value)
+		}
+	}
+	if hasOwn {
+		return &own
+	}
+	return &star
+}
+
+// robotsProductToken lấy product token ở đầu giá trị User-agent (chữ, "_" và "-", RFC 9309),
+// vd "webcrawler/1.0" thành "webcrawler". Nhóm chỉ áp dụng khi token trùng khớp với tên crawler,
+// không so chuỗi con để "crawler" hay "web" không nhận nhầm nhóm của bot khác.
+func robotsProductToken(ua string) string {
+	end := strings.IndexFunc(ua, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || r == '-')
+	})
+	if end < 0 {
+		return ua
+	}
+	return ua[:end]
+}
+
+func (r *robotsRules) add(key, value string) {
+	switch key {
+	case "allow", "disallow":
+		// Disallow rỗng nghĩa là không cấm gì
+		if value != "" {
+			r.rules = append(r.rules, newRobotsRule(key == "allow", value))
+		}
+	case "crawl-delay":
+		if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+			r.delay = time.Duration(secs * float64(time.Second))
+		}
+	}
+}
+
+// newRobotsRule dịch mẫu đường dẫn (* là chuỗi bất kỳ, $ ở cuối là hết url) sang regexp.
+func newRobotsRule(allow bool, pattern string) robotsRule {
+	anchored := strings.HasSuffix(pattern, "$")
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(strings.TrimSuffix(pattern, "$")), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return robotsRule{allow: allow, pattern: pattern, re: regexp.MustCompile(expr)}
+}
+
+// allowed kiểm tra đường dẫn (kèm query) theo quy tắc khớp dài nhất, bằng nhau thì Allow thắng.
+func (r *robotsRules) allowed(path string) bool {
+	if r.disallowAll {
+		return path == "/robots.txt"
+	}
+	best, allow := -1, true
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if n := len(rule.pattern); n > best || (n == best && rule.allow) {
+			best, allow = n, rule.allow
+		}
+	}
+	return allow
+}
+
+type robotsEntry struct {
+	done    chan struct{}
+	rules   *robotsRules
+	expires time.Time
+}
+
+// robotsCache giữ robots.txt theo scheme+host, mỗi host chỉ tải một lần dù nhiều request cùng chờ.
+type robotsCache struct {
+	mu      sync.Mutex
+	entries map[string]*robotsEntry
+}
+
+var robots = &robotsCache{entries: map[string]*robotsEntry{}}
+
+// get trả về quy tắc robots.txt của host chứa u, tải lại khi hết hạn.
+func (c *robotsCache) get(ctx context.Context, s *Site, u *neturl.URL) (*robotsRules, error) {
+	key := u.Scheme + "://" + u.Host
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if ok {
+		select {
+		case <-e.done:
+			if time.Now().After(e.expires) {
+				ok = false
+			}
+		default:
+		}
+	}
+	if !ok {
+		e = &robotsEntry{done: make(chan struct{})}
+		c.entries[key] = e
+		c.mu.Unlock()
+		e.rules, e.expires = s.fetchRobots(ctx, key+"/robots.txt")
+		sharedFetcher().setDelay(u.Host, e.rules.delay)
+		close(e.done)
+		return e.rules, nil
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-e.done:
+		return e.rules, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetchRobots tải robots.txt theo RFC 9309: 4xx là không cấm gì,
+// lỗi server hoặc mạng thì tạm coi như cấm toàn bộ và thử lại sau robotsRetry.
+func (s *Site) fetchRobots(ctx context.Context, url string) (*robotsRules, time.Time) {
+	logger := logging.From(ctx).With(logging.KeyStage, "robots", logging.KeyURL, url)
+	ttl := config.GetEnvDuration("ROBOTS_CACHE_TTL", defaultRobotsTTL)
+	// kết quả dùng chung cho các request khác nên không để ctx của request này hủy giữa chừng
+	ctx = context.WithoutCancel(ctx)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return &robotsRules{disallowAll: true}, time.Now().Add(robotsRetry)
+	}
+	s.setHeaders(req)
+	release, err := sharedFetcher().acquire(ctx, s.Name, req.URL.Host)
+	if err != nil {
+		return &robotsRules{disallowAll: true}, time.Now().Add(robotsRetry)
+	}
+	defer release()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Warn("không tải được robots.txt, tạm dừng crawl host", "error", err)
+		return &robotsRules{disallowAll: true}, time.Now().Add(robotsRetry)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		logger.Warn("robots.txt lỗi server, tạm dừng crawl host", "status", resp.StatusCode)
+		return &robotsRules{disallowAll: true}, time.Now().Add(robotsRetry)
+	case resp.StatusCode >= 400:
+		logger.Debug("không có robots.txt", "status", resp.StatusCode)
+		return &robotsRules{}, time.Now().Add(ttl)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, robotsMaxSize))
+	if err != nil {
+		logger.Warn("không tải được robots.txt, tạm dừng crawl host", "error", err)
+		return &robotsRules{disallowAll: true}, time.Now().Add(robotsRetry)
+	}
+	rules := parseRobots(data, robotsAgent())
+	logger.Debug("đã tải robots.txt", "rules", len(rules.rules), "crawl_delay", rules.delay)
+	return rules, time.Now().Add(ttl)
+}
+
+// checkRobots trả ErrRobotsDisallowed nếu robots.txt cấm tải u, trừ khi site được phép bỏ qua.
+func (s *Site) checkRobots(ctx context.Context, u *neturl.URL) error {
+	if s.IgnoreRobots {
+		return nil
+	}
+	rules, err := robots.get(ctx, s, u)
+	if err != nil {
+		return err
+	}
+	if rules.disallowAll {
+		return fmt.Errorf("%w %s: không tải được robots.txt", ErrRobotsDisallowed, u)
+	}
+	if !rules.allowed(u.RequestURI()) {
+		return fmt.Errorf("%w %s", ErrRobotsDisallowed, u)
+	}
+	return nil
+}
+
+// userAgent là User-Agent gửi tới mọi site: USER_AGENT nếu có, nếu không thì
+// "webcrawler/1.0 (+mailto:<liên hệ>)" với liên hệ là CRAWLER_CONTACT hoặc SMTP_FROM.
+func userAgent() string {
+	if ua := config.GetEnv("USER_AGENT", ""); ua != "" {
+		return ua
+	}
+	contact := config.GetEnv("CRAWLER_CONTACT", config.GetEnv("SMTP_FROM", ""))
+	if contact == "" {
+		return "webcrawler/1.0"
+	}
+	if strings.Contains(contact, "@") && !strings.HasPrefix(contact, "mailto:") {
+		contact = "mailto:" + contact
+	}
+	return "webcrawler/1.0 (+" + contact + ")"
+}
+
+// robotsAgent là tên sản phẩm đầu User-Agent, dùng để chọn nhóm trong robots.txt.
+func robotsAgent() string {
+	ua := userAgent()
+	if i := strings.IndexAny(ua, "/ "); i > 0 {
+		ua = ua[:i]
+	}
+	return ua
+}
+
+// setHeaders đặt User-Agent rồi các header riêng của site (ghi đè được cả User-Agent).
+func (s *Site) setHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", userAgent())
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+}
+
+// parseHeaders đọc danh sách header dạng "Tên: giá trị|Tên: giá trị".
+func parseHeaders(s string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, part := range strings.Split(s, "|") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(part, ":")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("header %q không hợp lệ, cần dạng Tên: giá trị", part)
+		}
+		out[http.CanonicalHeaderKey(strings.TrimSpace(k))] = strings.TrimSpace(v)
+	}
+	return out, nil
+}
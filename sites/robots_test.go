@@ -0,0 +1,129 @@
+package sites
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseRobots(t *testing.T) {
+	const data = `# ví dụ
+User-agent: *
+Disallow: /private/
+Crawl-delay: 1
+
+User-agent: Googlebot
+User-agent: webcrawler
+Disallow: /admin
+Disallow: /*.pdf$
+Allow: /admin/public
+Disallow: /tim?*q=
+Crawl-delay: 2.5
+`
+	own := parseRobots([]byte(data), "webcrawler")
+	if own.delay != 2500*time.Millisecond {
+		t.Errorf("delay = %v, muốn 2.5s", own.delay)
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/private/a.html", true}, // nhóm riêng thay cho nhóm *
+		{"/admin", false},
+		{"/admin/users", false},
+		{"/admin/public/a.html", true},
+		{"/files/a.pdf", false},
+		{"/files/a.pdf?x=1", true},
+		{"/tim?s=1&q=tuyen", false},
+		{"/robots.txt", true},
+	}
+	for _, tt := range tests {
+		if got := own.allowed(tt.path); got != tt.want {
+			t.Errorf("allowed(%q) = %v, muốn %v", tt.path, got, tt.want)
+		}
+	}
+
+	other := parseRobots([]byte(data), "otherbot")
+	if other.allowed("/private/a.html") || !other.allowed("/admin") || other.delay != time.Second {
+		t.Errorf("nhóm * không đúng: %+v", other)
+	}
+	if empty := parseRobots(nil, "webcrawler"); !empty.allowed("/bat-ky") {
+		t.Error("robots.txt rỗng phải cho phép mọi đường dẫn")
+	}
+}
+
+// Nhóm được chọn theo product token khớp nguyên vẹn, không phân biệt hoa thường, không có thì dùng nhóm *.
+func TestParseRobotsAgent(t *testing.T) {
+	tests := []struct {
+		name, group string
+		own         bool
+	}{
+		{"đúng tên", "webcrawler", true},
+		{"khác hoa thường", "WebCrawler", true},
+		{"kèm phiên bản", "webcrawler/1.0", true},
+		{"kèm mô tả", "webcrawler (+mailto:a@b.vn)", true},
+		{"chuỗi con của tên", "crawler", false},
+		{"tiền tố của tên", "web", false},
+		{"tên dài hơn", "webcrawler-pro", false},
+		{"bot khác", "Googlebot", false},
+	}
+	for _, tt := range tests {
+		data := "User-agent: *\nDisallow: /chung\n\nUser-agent: " + tt.group + "\nDisallow: /rieng\n"
+		r := parseRobots([]byte(data), "webcrawler")
+		if got := !r.allowed("/rieng") && r.allowed("/chung"); got != tt.own {
+			t.Errorf("%s (%q): dùng nhóm riêng = %v, muốn %v", tt.name, tt.group, got, tt.own)
+		}
+	}
+}
+
+func TestCheckRobots(t *testing.T) {
+	t.Setenv("USER_AGENT", "")
+	t.Setenv("CRAWLER_CONTACT", "ops@example.com")
+	var robotsHits int
+	var gotAgent, gotReferer string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			robotsHits++
+			w.Write([]byte("User-agent: webcrawler\nDisallow: /cam\nCrawl-delay: 0.1\n"))
+		default:
+			gotAgent, gotReferer = r.UserAgent(), r.Referer()
+			w.Write([]byte("<html></html>"))
+		}
+	}))
+	defer srv.Close()
+	f := newFetchScheduler(8, 8, 0)
+	withFetcher(t, f)
+	withRobots(t)
+
+	s := &Site{Name: "test", Headers: map[string]string{"Referer": "https://example.com/"}}
+	if _, _, err := s.fetchBody(context.Background(), srv.URL+"/cam/1"); !errors.Is(err, ErrRobotsDisallowed) {
+		t.Fatalf("err = %v, muốn ErrRobotsDisallowed", err)
+	}
+	if _, _, err := s.fetchBody(context.Background(), srv.URL+"/tin/1"); err != nil {
+		t.Fatal(err)
+	}
+	if robotsHits != 1 {
+		t.Errorf("robots.txt được tải %d lần, muốn 1", robotsHits)
+	}
+	if want := "webcrawler/1.0 (+mailto:ops@example.com)"; gotAgent != want {
+		t.Errorf("User-Agent = %q, muốn %q", gotAgent, want)
+	}
+	if gotReferer != "https://example.com/" {
+		t.Errorf("Referer = %q", gotReferer)
+	}
+	u, _ := url.Parse(srv.URL)
+	if d := f.host(u.Host).delay; d != 100*time.Millisecond {
+		t.Errorf("khoảng cách host = %v, muốn Crawl-delay 100ms", d)
+	}
+
+	s.IgnoreRobots = true
+	if _, _, err := s.fetchBody(context.Background(), srv.URL+"/cam/1"); err != nil {
+		t.Errorf("site được phép bỏ qua robots.txt: %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package sites
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"webcrawler/config"
+	"webcrawler/healthcheck"
+	"webcrawler/helpers"
+	"webcrawler/logging"
+	"webcrawler/metrics"
+)
+
+// SiteResult là kết quả crawl một site trong một lần chạy.
+type SiteResult struct {
+	Site     string
+	Started  time.Time
+	Finished time.Time
+	Stats    Stats
+	Err      error
+}
+
+// Run crawl song song các site, gộp tin trùng giữa các site rồi mới gửi email, và ghi lịch sử lần chạy vào database
+// (trừ khi chạy thử). Lỗi trả về gộp lỗi của từng site.
+func Run(ctx context.Context, targets []*Site, opts Options) ([]SiteResult, error) {
+	started := helpers.Now()
+	var runID int64
+	if !opts.DryRun {
+		id, err := config.StartRun(started)
+		if err != nil {
+			// không ghi được lịch sử vẫn crawl bình thường
+			logging.From(ctx).Warn("không ghi được lịch sử chạy", "error", err)
+		}
+		runID = id
+	}
+	// lần chạy không ghi vào database vẫn cần run_id để gom log
+	logID := strconv.FormatInt(runID, 10)
+	if runID == 0 {
+		logID = "local-" + strconv.FormatInt(started.UnixNano(), 36)
+	}
+	ctx = logging.With(ctx, logging.KeyRunID, logID)
+
+	// tải tin của mọi site trước rồi mới gửi, để gộp được tin trùng giữa các site
+	results := make([]SiteResult, len(targets))
+	crawls := make([]*siteCrawl, len(targets))
+	checks := make([]healthcheck.Check, len(targets))
+	var wg sync.WaitGroup
+	for i, site := range targets {
+		wg.Add(1)
+		go func(i int, site *Site) {
+			defer wg.Done()
+			checks[i] = site.Healthcheck
+			if opts.DryRun {
+				checks[i] = ""
+			}
+			checks[i].Start(ctx)
+			results[i] = SiteResult{Site: site.Name, Started: helpers.Now()}
+			crawls[i], results[i].Err = site.collect(ctx, opts)
+		}(i, site)
+	}
+	wg.Wait()
+
+	var collected []*siteCrawl
+	for i, c := range crawls {
+		if results[i].Err == nil {
+			collected = append(collected, c)
+		}
+	}
+	deliverAll(ctx, collected, opts)
+
+	for i, site := range targets {
+		wg.Add(1)
+		go func(i int, site *Site) {
+			defer wg.Done()
+			res := &results[i]
+			if res.Err == nil {
+				res.Stats, res.Err = crawls[i].finish(ctx, opts)
+			} else {
+				res.Stats = crawls[i].stats.snapshot()
+			}
+			res.Finished = helpers.Now()
+			logger := logging.From(ctx).With(logging.KeySite, site.Name, logging.KeyStage, "done")
+			if res.Err != nil {
+				logger.Error("crawl site lỗi", "stats", res.Stats, "error", res.Err)
+			} else {
+				logger.Info("crawl site xong", "stats", res.Stats)
+			}
+			if runID != 0 {
+				if err := config.RecordSiteRun(res.record(runID)); err != nil {
+					logger.Warn("không ghi được lịch sử chạy", "error", err)
+				}
+			}
+			checks[i].Finish(ctx, res.Err, res.String())
+			if !opts.DryRun {
+				res.observe()
+			}
+		}(i, site)
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, res := range results {
+		if res.Err != nil {
+			errs = append(errs, res.Err)
+		}
+	}
+	err := errors.Join(errs...)
+	if runID != 0 {
+		status, errText := "success", ""
+		switch {
+		case ctx.Err() != nil:
+			status, errText = "interrupted", ctx.Err().Error()
+		case err != nil:
+			status, errText = "failed", err.Error()
+		}
+		if err := config.FinishRun(runID, started, helpers.Now(), status, errText); err != nil {
+			logging.From(ctx).Warn("không ghi được lịch sử chạy", "error", err)
+		}
+	}
+	if err != nil {
+		return results, fmt.Errorf("%d site lỗi: %w", len(errs), err)
+	}
+	return results, nil
+}
+
+// BySchedule chia các site thành nhóm cùng lịch cron, giữ thứ tự xuất hiện. Chạy chung một nhóm bằng Run
+// thì tin trùng giữa các site cùng lúc được gộp vào một email.
+func BySchedule(targets []*Site) [][]*Site {
+	index := map[string]int{}
+	var groups [][]*Site
+	for _, s := range targets {
+		g, ok := index[s.Schedule]
+		if !ok {
+			g = len(groups)
+			index[s.Schedule] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], s)
+	}
+	return groups
+}
+
+// String trả về một dòng tóm tắt kết quả, dùng cho log và healthcheck.
+func (r SiteResult) String() string {
+	return fmt.Sprintf("%s: %s (%s)", r.Site, r.Stats, r.Finished.Sub(r.Started).Round(time.Second))
+}
+
+// Summary tóm tắt kết quả các site, mỗi site một dòng.
+func Summary(results []SiteResult) string {
+	lines := make([]string, len(results))
+	for i, r := range results {
+		lines[i] = r.String()
+		if r.Err != nil {
+			lines[i] += "\n  " + r.Err.Error()
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Health giữ kết quả lần chạy gần nhất của từng site, khi các site chạy ở các job khác nhau
+// nhưng dùng chung một healthcheck.
+type Health struct {
+	mu     sync.Mutex
+	failed map[string]error
+}
+
+// Update ghi nhận kết quả các site vừa chạy và trả về lỗi của mọi site mà lần chạy gần nhất bị lỗi,
+// nil nếu không còn site nào lỗi.
+func (h *Health) Update(results []SiteResult) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.failed == nil {
+		h.failed = map[string]error{}
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			h.failed[r.Site] = r.Err
+		} else {
+			delete(h.failed, r.Site)
+		}
+	}
+	if len(h.failed) == 0 {
+		return nil
+	}
+	names := slices.Sorted(maps.Keys(h.failed))
+	errs := make([]error, len(names))
+	for i, name := range names {
+		errs[i] = fmt.Errorf("%s: %w", name, h.failed[name])
+	}
+	return fmt.Errorf("%d site lỗi ở lần chạy gần nhất: %w", len(names), errors.Join(errs...))
+}
+
+// observe cập nhật metric của site sau một lần crawl.
+func (r SiteResult) observe() {
+	metrics.Items.Add(float64(r.Stats.ListItems), r.Site, "found")
+	metrics.Items.Add(float64(r.Stats.New), r.Site, "new")
+	metrics.Items.Add(float64(r.Stats.Sent), r.Site, "sent")
+	metrics.Items.Add(float64(r.Stats.Failed+r.Stats.DetailMissing), r.Site, "failed")
+	metrics.Items.Add(float64(r.Stats.Updated), r.Site, "updated")
+	metrics.Items.Add(float64(r.Stats.Duplicates), r.Site, "duplicate")
+	metrics.RunDuration.Set(r.Finished.Sub(r.Started).Seconds(), r.Site)
+
+	last := r.Finished
+	if r.Err != nil {
+		// chạy một lần thì file metric được ghi lại từ đầu, lấy mốc thành công từ lịch sử
+		var err error
+		if last, err = config.LastSiteSuccess(r.Site); err != nil || last.IsZero() {
+			return
+		}
+	}
+	metrics.LastSuccess.Set(float64(last.Unix()), r.Site)
+}
+
+func (r SiteResult) record(runID int64) config.SiteRun {
+	run := config.SiteRun{
+		RunID:      runID,
+		Site:       r.Site,
+		StartedAt:  r.Started,
+		FinishedAt: r.Finished,
+		Pages:      r.Stats.Pages,
+		Items:      r.Stats.ListItems,
+		New:        r.Stats.New,
+		Sent:       r.Stats.Sent,
+		Failed:     r.Stats.Failed + r.Stats.DetailMissing,
+	}
+	if r.Err != nil {
+		run.Error = r.Err.Error()
+	}
+	return run
+}
@@ -0,0 +1,26 @@
+package sites
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Site chạy tốt ở job khác không được xóa lỗi của site đang lỗi trên healthcheck chung.
+func TestHealth(t *testing.T) {
+	var h Health
+	if err := h.Update([]SiteResult{{Site: "hvtp"}}); err != nil {
+		t.Fatalf("không site nào lỗi: %v", err)
+	}
+	err := h.Update([]SiteResult{{Site: "bvhh", Err: errors.New("HTTP 500")}})
+	if err == nil || !strings.Contains(err.Error(), "bvhh: HTTP 500") {
+		t.Fatalf("bvhh lỗi: %v", err)
+	}
+	err = h.Update([]SiteResult{{Site: "hvtp"}})
+	if err == nil || !strings.Contains(err.Error(), "bvhh") || strings.Contains(err.Error(), "hvtp") {
+		t.Errorf("hvtp chạy tốt, bvhh vẫn lỗi: %v", err)
+	}
+	if err := h.Update([]SiteResult{{Site: "bvhh"}}); err != nil {
+		t.Errorf("bvhh đã chạy tốt lại: %v", err)
+	}
+}
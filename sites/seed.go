@@ -0,0 +1,86 @@
+package sites
+
+import (
+	"context"
+	"fmt"
+	"webcrawler/config"
+	"webcrawler/logging"
+)
+
+// SeedResult là kết quả một lần seed.
+type SeedResult struct {
+	Pages       int
+	Found       int
+	AlreadySent int
+	Marked      int
+	Stored      int
+}
+
+// Seed crawl tối đa pages trang danh sách và ghi mọi tin tìm được là đã gửi mà không
+// gửi email, để lần crawl sau chỉ báo tin thật sự mới. Tin không khớp từ khóa cũng được
+// ghi để đổi từ khóa sau này không gửi lại tin cũ. storeContent tải thêm trang chi tiết
+// và lưu nội dung tin, đánh dấu là tin seed để không bị kiểm tra lại và gửi email cập nhật.
+// Khi opts.DryRun chỉ đếm, không ghi database.
+func (s *Site) Seed(ctx context.Context, pages int, storeContent bool, opts Options) (SeedResult, error) {
+	var res SeedResult
+	seen := map[string]bool{}
+	for page := 1; page <= pages; page++ {
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+		url := s.PageURL(page)
+		if url == "" {
+			logging.From(ctx).Warn("site chưa có đường dẫn phân trang, đặt "+s.envKey("PAGE_PATH")+" để seed thêm trang",
+				logging.KeySite, s.Name, logging.KeyStage, "seed", "last_page", page-1)
+			break
+		}
+		items, err := s.listItems(ctx, url)
+		if err != nil {
+			return res, err
+		}
+		res.Pages++
+
+		added := 0
+		for _, a := range items {
+			if seen[a.URL] {
+				continue
+			}
+			seen[a.URL] = true
+			added++
+			res.Found++
+			if config.IsLinkSent(a.URL) {
+				res.AlreadySent++
+				continue
+			}
+			if opts.DryRun {
+				res.Marked++
+				continue
+			}
+			if storeContent {
+				if full, err := s.fetchDetail(ctx, a); err != nil {
+					logging.From(ctx).Warn("lỗi trang chi tiết", logging.KeySite, s.Name, logging.KeyStage, "seed",
+						logging.KeyURL, a.URL, "error", err)
+				} else {
+					rec := full.record()
+					rec.Seeded = true
+					config.SaveArticle(rec)
+					res.Stored++
+				}
+			}
+			if err := config.MarkLinkAsSeeded(a.URL); err != nil {
+				return res, err
+			}
+			res.Marked++
+		}
+		// trang rỗng hoặc lặp lại trang trước (site bỏ qua tham số trang) thì dừng
+		if added == 0 {
+			break
+		}
+	}
+	return res, nil
+}
+
+func (r SeedResult) String() string {
+	return fmt.Sprintf("%d trang, %d tin, %d đã có, %d ghi mới, %d lưu nội dung",
+		r.Pages, r.Found, r.AlreadySent, r.Marked, r.Stored)
+}
@@ -0,0 +1,676 @@
+package sites
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	neturl "net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"webcrawler/config"
+	"webcrawler/extract"
+	"webcrawler/healthcheck"
+	"webcrawler/helpers"
+	"webcrawler/ics"
+	"webcrawler/logging"
+	"webcrawler/metrics"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/text/unicode/norm"
+)
+
+// UndatedPolicy quyết định cách xử lý tin không xác định được ngày đăng.
+type UndatedPolicy string
+
+const (
+	// UndatedSend vẫn gửi tin không có ngày đăng
+	UndatedSend UndatedPolicy = "send"
+	// UndatedSkip bỏ qua tin không có ngày đăng
+	UndatedSkip UndatedPolicy = "skip"
+)
+
+// Article là một tin lấy từ trang danh sách, được bổ sung nội dung ở trang chi tiết.
+type Article struct {
+	Site      string
+	Title     string
+	URL       string
+	Published time.Time // zero nếu chưa biết ngày đăng
+	HTML      string    // nội dung gửi email
+	Text      string    // nội dung dạng text thuần, dùng để trích thông tin
+	// Recruitment là thông tin tuyển dụng trích từ Text
+	Recruitment extract.Recruitment
+}
+
+// Site mô tả một nguồn tin và cách bóc tách trang danh sách, trang chi tiết.
+type Site struct {
+	Name     string
+	BaseURL  string
+	ListPath string
+	// Keywords lọc theo tiêu đề, không phân biệt hoa thường, rỗng thì lấy tất cả
+	Keywords []string
+	// MaxAgeDays là tuổi tối đa của tin (ngày), 0 là không giới hạn.
+	// Ghi đè bằng biến môi trường <NAME>_MAX_AGE_DAYS.
+	MaxAgeDays int
+	// Undated ghi đè bằng biến môi trường <NAME>_UNDATED (send|skip).
+	Undated UndatedPolicy
+	// Schedule là biểu thức cron khi chạy ở chế độ serve, ghi đè bằng <NAME>_SCHEDULE
+	Schedule string
+	// PagePath là đường dẫn trang danh sách thứ n (n >= 2) với {page} thay cho số trang,
+	// rỗng nếu site không phân trang hoặc chưa kiểm tra cách phân trang trên site thật.
+	// Ghi đè bằng <NAME>_PAGE_PATH.
+	PagePath string
+	// DetailDateSelector dùng để tìm ngày đăng ở trang chi tiết khi danh sách không có
+	DetailDateSelector string
+	// Disabled ghi đè bằng <NAME>_ENABLED=true|false
+	Disabled bool
+	// Healthcheck là địa chỉ ping riêng của site, đặt bằng <NAME>_HEALTHCHECK_URL
+	Healthcheck healthcheck.Check
+	// Headers là các header riêng gửi kèm mọi request tới site, ghi đè bằng
+	// <NAME>_HEADERS="Tên: giá trị|Tên: giá trị"
+	Headers map[string]string
+	// IgnoreRobots bỏ qua robots.txt, chỉ bật khi site đã đồng ý. Ghi đè bằng <NAME>_IGNORE_ROBOTS.
+	IgnoreRobots bool
+	// Charset ép bảng mã của trang (vd windows-1258, tcvn3), rỗng là tự nhận biết.
+	// Ghi đè bằng <NAME>_CHARSET.
+	Charset string
+	// Feed khi trang danh sách là feed RSS/Atom, khai báo bằng FEEDS thay vì viết ParseList
+	Feed bool
+	// ContentSelector là selector nội dung trang chi tiết của nguồn feed, rỗng thì dùng nội dung
+	// trong feed. Ghi đè bằng <NAME>_CONTENT_SELECTOR.
+	ContentSelector string
+
+	ParseList   func(s *Site, doc *goquery.Document) []Article
+	ParseDetail func(s *Site, doc *goquery.Document, a *Article) error
+}
+
+var client = &http.Client{
+	Timeout: 60 * time.Second,
+	Transport: &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	},
+}
+
+// WrapTransport bọc transport của client dùng chung, ví dụ để ghi/phát lại phản hồi.
+func WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	client.Transport = wrap(client.Transport)
+}
+
+// mặc định chạy mỗi giờ như crontab cũ
+const defaultSchedule = "0 * * * *"
+
+var registry = []*Site{
+	vcaDocsSite,
+	vcaNewsSite,
+	departmentSite,
+	hvtpSite,
+	bvhttdlSite,
+	bvhhSite,
+}
+
+// All trả về danh sách site cùng các nguồn feed trong FEEDS, đã áp dụng cấu hình từ biến môi trường.
+func All() []*Site {
+	out := make([]*Site, 0, len(registry))
+	for _, s := range append(slices.Clip(registry), feedSites()...) {
+		c := *s
+		c.loadEnv()
+		out = append(out, &c)
+	}
+	return out
+}
+
+func (s *Site) envKey(suffix string) string {
+	return strings.ToUpper(s.Name) + "_" + suffix
+}
+
+func (s *Site) loadEnv() {
+	if s.Schedule == "" {
+		s.Schedule = defaultSchedule
+	}
+	s.Schedule = config.GetEnv(s.envKey("SCHEDULE"), s.Schedule)
+	s.PagePath = config.GetEnv(s.envKey("PAGE_PATH"), s.PagePath)
+	s.Disabled = !config.GetEnvBool(s.envKey("ENABLED"), !s.Disabled)
+	s.MaxAgeDays = config.GetEnvInt(s.envKey("MAX_AGE_DAYS"), s.MaxAgeDays)
+	s.Charset = config.GetEnv(s.envKey("CHARSET"), s.Charset)
+	s.ContentSelector = config.GetEnv(s.envKey("CONTENT_SELECTOR"), s.ContentSelector)
+	if v := config.GetEnv(s.envKey("KEYWORDS"), ""); v != "" {
+		s.Keywords = nil
+		for _, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				s.Keywords = append(s.Keywords, foldKeyword(k))
+			}
+		}
+	}
+	s.IgnoreRobots = config.GetEnvBool(s.envKey("IGNORE_ROBOTS"), s.IgnoreRobots)
+	if v := config.GetEnv(s.envKey("HEADERS"), ""); v != "" {
+		headers, err := parseHeaders(v)
+		if err != nil {
+			slog.Warn("bỏ qua header riêng của site", logging.KeySite, s.Name, "error", err)
+		} else {
+			// map của registry dùng chung giữa các bản sao nên chép sang map mới
+			merged := maps.Clone(s.Headers)
+			if merged == nil {
+				merged = map[string]string{}
+			}
+			maps.Copy(merged, headers)
+			s.Headers = merged
+		}
+	}
+	s.Healthcheck = healthcheck.Check(config.GetEnv(s.envKey("HEALTHCHECK_URL"), string(s.Healthcheck)))
+	switch p := UndatedPolicy(strings.ToLower(config.GetEnv(s.envKey("UNDATED"), string(s.Undated)))); p {
+	case UndatedSend, UndatedSkip:
+		s.Undated = p
+	default:
+		slog.Warn("chính sách tin không có ngày không hợp lệ", logging.KeySite, s.Name, "undated", p, "default", UndatedSend)
+		s.Undated = UndatedSend
+	}
+}
+
+// ListURL trả về địa chỉ trang danh sách.
+func (s *Site) ListURL() string {
+	return s.BaseURL + s.ListPath
+}
+
+// PageURL trả về địa chỉ trang danh sách thứ page (bắt đầu từ 1), rỗng nếu site không có trang đó.
+func (s *Site) PageURL(page int) string {
+	if page <= 1 {
+		return s.ListURL()
+	}
+	if s.PagePath == "" {
+		return ""
+	}
+	return s.BaseURL + strings.ReplaceAll(s.PagePath, "{page}", strconv.Itoa(page))
+}
+
+// Crawl tải trang danh sách, lọc tin theo từ khóa, tuổi tin, link đã gửi
+// rồi tải trang chi tiết và gửi email cho từng tin mới.
+func (s *Site) Crawl(ctx context.Context, opts Options) (Stats, error) {
+	c, err := s.collect(ctx, opts)
+	if err != nil {
+		return c.stats.snapshot(), err
+	}
+	deliverAll(ctx, []*siteCrawl{c}, opts)
+	return c.finish(ctx, opts)
+}
+
+// siteCrawl là một lần crawl site. Các tin mới tải xong được giữ lại để chỉ gửi khi
+// mọi site trong lần chạy đã tải xong, nhờ đó gộp được tin trùng giữa các site.
+type siteCrawl struct {
+	site      *Site
+	stats     runStats
+	validator config.PageValidator
+
+	mu    sync.Mutex
+	ready []Article
+}
+
+func (c *siteCrawl) add(a Article) {
+	c.mu.Lock()
+	c.ready = append(c.ready, a)
+	c.mu.Unlock()
+}
+
+// collect tải trang danh sách, lọc tin rồi tải trang chi tiết của các tin mới, chưa gửi email.
+func (s *Site) collect(ctx context.Context, opts Options) (*siteCrawl, error) {
+	ctx = logging.With(ctx, logging.KeySite, s.Name)
+	logger := logging.From(ctx)
+	c := &siteCrawl{site: s}
+	items, validator, unchanged, err := s.fetchList(ctx, opts)
+	if err != nil {
+		return c, err
+	}
+	c.validator = validator
+	c.stats.Pages, c.stats.ListItems = 1, len(items)
+	if unchanged {
+		logger.Info("trang danh sách không đổi từ lần chạy trước, bỏ qua site", logging.KeyStage, "list", logging.KeyURL, s.ListURL())
+		c.stats.Unchanged = true
+		s.recheckSent(ctx, opts, &c.stats)
+		return c, nil
+	}
+	if len(items) == 0 {
+		logger.Warn("trang danh sách không có tin nào", logging.KeyStage, "list", logging.KeyURL, s.ListURL())
+	}
+
+	// số request đồng thời do bộ điều phối tải trang dùng chung giới hạn
+	var wg sync.WaitGroup
+	for i, a := range items {
+		// khi dừng chương trình không tải thêm tin, chỉ chờ các tin đang xử lý
+		if ctx.Err() != nil {
+			break
+		}
+		if len(s.Keywords) > 0 && !findKeyword(a.Title, s.Keywords) {
+			continue
+		}
+		if !a.Published.IsZero() && s.tooOld(ctx, a) {
+			continue
+		}
+		if config.IsLinkSent(a.URL) {
+			logger.Debug("tin đã gửi", logging.KeyStage, "list", logging.KeyURL, a.URL, "index", i+1)
+			continue
+		}
+		c.stats.New++
+		wg.Add(1)
+		go func(a Article) {
+			defer wg.Done()
+			s.crawlDetail(ctx, a, c)
+		}(a)
+	}
+	wg.Wait()
+	s.recheckSent(ctx, opts, &c.stats)
+	return c, nil
+}
+
+// finish kết thúc lần crawl sau khi đã gửi email: kiểm tra selector và lưu thông tin trang danh sách.
+func (c *siteCrawl) finish(ctx context.Context, opts Options) (Stats, error) {
+	s := c.site
+	ctx = logging.With(ctx, logging.KeySite, s.Name)
+	st := c.stats.snapshot()
+	if err := ctx.Err(); err != nil {
+		return st, fmt.Errorf("%s: bị dừng: %w", s.Name, err)
+	}
+	if !opts.DryRun {
+		s.checkSelectors(ctx, st)
+	}
+	// chỉ lưu khi mọi tin đã xử lý xong, tin lỗi sẽ được thử lại dù danh sách không đổi
+	if c.validator.URL != "" && st.Failed == 0 && st.DetailMissing == 0 {
+		if err := config.SavePageValidator(c.validator); err != nil {
+			logging.From(ctx).Warn("không lưu được thông tin trang danh sách", logging.KeyStage, "list", "error", err)
+		}
+	}
+	return st, nil
+}
+
+// listItems tải một trang danh sách và trả về các tin trên trang.
+func (s *Site) listItems(ctx context.Context, url string) ([]Article, error) {
+	p, err := s.fetch(ctx, url, config.PageValidator{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	items, err := s.parseList(ctx, url, p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	return items, nil
+}
+
+// parseList bóc các tin trên một trang danh sách (hoặc feed) đã tải.
+func (s *Site) parseList(ctx context.Context, url string, p page) ([]Article, error) {
+	var items []Article
+	if s.Feed {
+		var err error
+		if items, err = parseFeed(p.body, url); err != nil {
+			return nil, fmt.Errorf("%s: %w", url, err)
+		}
+	} else {
+		doc, err := s.parseHTML(ctx, url, p)
+		if err != nil {
+			return nil, err
+		}
+		items = s.ParseList(s, doc)
+	}
+	for i := range items {
+		items[i].Site = s.Name
+		items[i].Title = strings.TrimSpace(items[i].Title)
+	}
+	return items, nil
+}
+
+// crawlDetail tải trang chi tiết của tin a, tin qua được bộ lọc được giữ lại trong c để gửi.
+func (s *Site) crawlDetail(ctx context.Context, a Article, c *siteCrawl) {
+	ctx = logging.With(ctx, logging.KeyURL, a.URL)
+	logger := logging.From(ctx)
+	logger.Info("đang crawl trang chi tiết", logging.KeyStage, "detail")
+	a, err := s.fetchDetail(ctx, a)
+	if err != nil && ctx.Err() != nil {
+		logger.Info("bỏ qua tin do đang dừng", logging.KeyStage, "detail")
+		return
+	}
+	if errors.Is(err, ErrRobotsDisallowed) {
+		logger.Info("bỏ qua tin do robots.txt", logging.KeyStage, "detail", "error", err)
+		return
+	}
+	if err != nil {
+		logger.Warn("lỗi trang chi tiết", logging.KeyStage, "detail", "error", err)
+		c.stats.add(func(st *Stats) {
+			if errors.Is(err, ErrSelectorMissing) {
+				st.DetailMissing++
+			} else {
+				st.Failed++
+			}
+		})
+		return
+	}
+	c.stats.add(func(st *Stats) { st.DetailOK++ })
+
+	if a.Published.IsZero() {
+		if s.Undated == UndatedSkip {
+			logger.Info("bỏ qua tin không có ngày đăng", logging.KeyStage, "filter")
+			return
+		}
+	} else if s.tooOld(ctx, a) {
+		return
+	}
+	c.add(a)
+}
+
+// fetchDetail tải trang chi tiết, bóc nội dung, ngày đăng và thông tin tuyển dụng.
+// Nguồn feed không có selector nội dung thì dùng nội dung trong feed, không tải trang chi tiết.
+func (s *Site) fetchDetail(ctx context.Context, a Article) (Article, error) {
+	if s.hasDetailPage() {
+		doc, err := s.fetchDocument(ctx, a.URL)
+		if err != nil {
+			return a, fmt.Errorf("lỗi khi tải trang chi tiết: %w", err)
+		}
+		parse := s.ParseDetail
+		if s.Feed {
+			parse = (*Site).parseContent
+		}
+		if err := parse(s, doc, &a); err != nil {
+			return a, fmt.Errorf("%s: %w", a.URL, err)
+		}
+		if a.Title == "" {
+			a.Title = strings.TrimSpace(doc.Find("title").First().Text())
+		}
+		if a.Published.IsZero() {
+			a.Published = detailDate(doc, s.DetailDateSelector)
+		}
+	} else {
+		feedContent(&a)
+	}
+
+	a.Text = helpers.HTMLToText(a.HTML)
+	a.Recruitment = extract.FromText(a.Title + "\n" + a.Text)
+	return a, nil
+}
+
+// deliver gửi email cho tin, ghi nhận link đã gửi, lưu tin và lịch nhắc hạn.
+// dups là các tin trùng ở site khác, được liệt kê trong email và cũng ghi nhận là đã gửi.
+// similar là các tin đã gửi có tiêu đề giống, được liệt kê để người nhận tự kiểm tra.
+// Khi chạy thử chỉ ghi email vào opts.Preview.
+func deliver(ctx context.Context, a Article, dups []Article, similar []recentArticle, opts Options) error {
+	subject, body, attachments := a.Subject(), a.Recruitment.HTML()+sourcesHTML(a, dups, similar)+a.HTML, a.attachments()
+	if opts.DryRun {
+		logging.From(ctx).Info("chạy thử, sẽ gửi email", logging.KeyStage, "deliver", logging.KeyURL, a.URL)
+		if opts.Preview != nil {
+			names := make([]string, len(attachments))
+			for i, at := range attachments {
+				names[i] = at.Name
+			}
+			opts.Preview.Add(a, subject, body, names)
+		}
+		return nil
+	}
+
+	err := config.SendEmail(subject, body, attachments...)
+	if err != nil {
+		return err
+	}
+	config.MarkLinkAsSent(a.URL)
+	for _, d := range dups {
+		config.MarkLinkAsSent(d.URL)
+	}
+	config.SaveArticle(a.record())
+	scheduleReminders(a)
+	return nil
+}
+
+// Find trả về site theo tên, nil nếu không có.
+func Find(name string) *Site {
+	for _, s := range All() {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// FindByURL trả về site có BaseURL cùng host với url, nil nếu không có.
+func FindByURL(rawURL string) *Site {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	for _, s := range All() {
+		base, err := neturl.Parse(s.BaseURL)
+		if err == nil && strings.EqualFold(base.Host, u.Host) {
+			return s
+		}
+	}
+	return nil
+}
+
+// Resend tải lại và gửi lại tin url, bỏ qua kiểm tra đã gửi và tuổi tin.
+func (s *Site) Resend(ctx context.Context, url string, opts Options) error {
+	a := Article{Site: s.Name, URL: url}
+	if stored, err := config.GetArticle(url); err == nil {
+		// nguồn feed không tải trang chi tiết nên gửi lại nội dung đã lưu
+		a.Title, a.HTML = stored.Title, stored.Content
+	}
+	a, err := s.fetchDetail(ctx, a)
+	if err != nil {
+		return err
+	}
+	return deliver(logging.With(ctx, logging.KeySite, s.Name, logging.KeyURL, url), a, nil, nil, opts)
+}
+
+// Subject trả về tiêu đề email kèm tóm tắt chỉ tiêu, hạn nộp nếu trích được.
+func (a Article) Subject() string {
+	if summary := a.Recruitment.Subject(); summary != "" {
+		return a.Title + " [" + summary + "]"
+	}
+	return a.Title
+}
+
+// attachments trả về file .ics hạn nộp, ngày thi nếu trích được.
+func (a Article) attachments() []config.Attachment {
+	events := ics.ArticleEvents(a.URL, a.Title, a.Recruitment)
+	if len(events) == 0 {
+		return nil
+	}
+	return []config.Attachment{{
+		Name:        "lich-tuyen-dung.ics",
+		ContentType: "text/calendar; charset=utf-8; method=PUBLISH",
+		Data:        ics.Calendar(a.Title, events),
+	}}
+}
+
+func (a Article) record() config.Article {
+	return config.Article{
+		URL:         a.URL,
+		Site:        a.Site,
+		Title:       a.Title,
+		PublishedAt: a.Published,
+		Content:     a.HTML,
+		Recruitment: a.Recruitment,
+		ContentHash: contentHash(a.HTML),
+	}
+}
+
+// tooOld kiểm tra tin đã quá MaxAgeDays chưa, tin có ngày trong tương lai không bị coi là cũ.
+func (s *Site) tooOld(ctx context.Context, a Article) bool {
+	age := helpers.DaysSince(a.Published)
+	if age < 0 {
+		logging.From(ctx).Warn("ngày đăng nằm trong tương lai", logging.KeyStage, "filter",
+			logging.KeyURL, a.URL, "published", a.Published.Format("02/01/2006"))
+		return false
+	}
+	if s.MaxAgeDays > 0 && age > s.MaxAgeDays {
+		logging.From(ctx).Debug("bỏ qua tin cũ", logging.KeyStage, "filter", logging.KeyURL, a.URL, "age_days", age)
+		return true
+	}
+	return false
+}
+
+func (s *Site) fetchDocument(ctx context.Context, url string) (*goquery.Document, error) {
+	p, err := s.fetch(ctx, url, config.PageValidator{})
+	if err != nil {
+		return nil, err
+	}
+	return s.parseHTML(ctx, url, p)
+}
+
+// parseHTML chuyển trang về UTF-8 rồi phân tích HTML.
+func (s *Site) parseHTML(ctx context.Context, url string, p page) (*goquery.Document, error) {
+	body, cs, err := decodeHTML(p.body, p.contentType, s.Charset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	if cs != "utf-8" {
+		logging.From(ctx).Debug("đã chuyển mã trang về UTF-8", logging.KeyURL, url, "charset", cs)
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khi phân tích HTML %s: %w", url, err)
+	}
+	return doc, nil
+}
+
+// fetchBody tải url và trả về nội dung gốc cùng header Content-Type, lỗi nếu mã trạng thái khác 200.
+func (s *Site) fetchBody(ctx context.Context, url string) ([]byte, string, error) {
+	p, err := s.fetch(ctx, url, config.PageValidator{})
+	return p.body, p.contentType, err
+}
+
+// page là phản hồi của một lần tải trang.
+type page struct {
+	body         []byte
+	contentType  string
+	etag         string
+	lastModified string
+	// notModified khi server trả 304 cho request có điều kiện, body rỗng
+	notModified bool
+}
+
+// fetch tải url. Khi prev có ETag hoặc Last-Modified thì gửi request có điều kiện,
+// server trả 304 thì page.notModified là true. Mã trạng thái khác là lỗi.
+func (s *Site) fetch(ctx context.Context, url string, prev config.PageValidator) (page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return page{}, err
+	}
+	s.setHeaders(req)
+	if prev.ETag != "" {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+	if prev.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.LastModified)
+	}
+	if err := s.checkRobots(ctx, req.URL); err != nil {
+		return page{}, err
+	}
+	release, err := sharedFetcher().acquire(ctx, s.Name, req.URL.Host)
+	if err != nil {
+		return page{}, err
+	}
+	defer release()
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		metrics.FetchDuration.Observe(time.Since(start).Seconds(), s.Name)
+		metrics.HTTPResponses.Inc(s.Name, "error")
+		return page{}, fmt.Errorf("lỗi khi tải %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	metrics.HTTPResponses.Inc(s.Name, strconv.Itoa(resp.StatusCode))
+	p := page{
+		contentType:  resp.Header.Get("Content-Type"),
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && (prev.ETag != "" || prev.LastModified != ""):
+		metrics.FetchDuration.Observe(time.Since(start).Seconds(), s.Name)
+		p.notModified = true
+		return p, nil
+	case resp.StatusCode != http.StatusOK:
+		metrics.FetchDuration.Observe(time.Since(start).Seconds(), s.Name)
+		return page{}, fmt.Errorf("HTTP %d khi tải %s", resp.StatusCode, url)
+	}
+
+	p.body, err = io.ReadAll(resp.Body)
+	metrics.FetchDuration.Observe(time.Since(start).Seconds(), s.Name)
+	if err != nil {
+		return page{}, fmt.Errorf("lỗi khi tải %s: %w", url, err)
+	}
+	return p, nil
+}
+
+// findKeyword cho biết s có chứa một trong các từ khóa hay không, không phân biệt hoa thường và
+// cách mã hóa dấu tiếng Việt (dựng sẵn hay tổ hợp).
+func findKeyword(s string, keywords []string) bool {
+	lower := foldKeyword(s)
+	for _, kw := range keywords {
+		if strings.Contains(lower, foldKeyword(kw)) {
+			return true
+		}
+	}
+	return false
+}
+
+// foldKeyword đưa chuỗi về chữ thường dạng NFC để so từ khóa.
+func foldKeyword(s string) string {
+	return norm.NFC.String(strings.ToLower(s))
+}
+
+// các thẻ meta thường chứa ngày đăng bài
+var metaDateSelectors = []string{
+	`meta[property="article:published_time"]`,
+	`meta[itemprop="datePublished"]`,
+	`meta[name="pubdate"]`,
+	`meta[name="publishdate"]`,
+	`meta[name="DC.date.issued"]`,
+	`meta[name="date"]`,
+	`meta[property="og:updated_time"]`,
+	`meta[property="article:modified_time"]`,
+}
+
+// detailDate tìm ngày đăng ở trang chi tiết: thẻ meta, thẻ <time>, rồi selector riêng của site.
+func detailDate(doc *goquery.Document, selector string) time.Time {
+	for _, sel := range metaDateSelectors {
+		if content, ok := doc.Find(sel).First().Attr("content"); ok {
+			if t, err := helpers.ParseDate(content); err == nil {
+				return t
+			}
+		}
+	}
+	if t := dateFromSelection(doc.Find("time").First()); !t.IsZero() {
+		return t
+	}
+	if selector != "" {
+		return dateFromSelection(doc.Find(selector).First())
+	}
+	return time.Time{}
+}
+
+// dateFromSelection đọc ngày từ thuộc tính datetime hoặc nội dung text, zero nếu không có.
+func dateFromSelection(sel *goquery.Selection) time.Time {
+	if sel.Length() == 0 {
+		return time.Time{}
+	}
+	if v, ok := sel.Attr("datetime"); ok {
+		if t, err := helpers.ParseDate(v); err == nil {
+			return t
+		}
+	}
+	if dt := sel.Find("time[datetime]").First(); dt.Length() > 0 {
+		v, _ := dt.Attr("datetime")
+		if t, err := helpers.ParseDate(v); err == nil {
+			return t
+		}
+	}
+	t, err := helpers.ParseDate(sel.Text())
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
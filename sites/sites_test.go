@@ -0,0 +1,161 @@
+package sites
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"webcrawler/extract"
+	"webcrawler/helpers"
+)
+
+var update = flag.Bool("update", false, "ghi lại testdata/<site>/golden.json từ kết quả hiện tại")
+
+// thời điểm cố định để ngày tương đối và tuổi tin không đổi theo ngày chạy test
+var fixtureNow = time.Date(2025, 3, 15, 10, 0, 0, 0, helpers.Location)
+
+// redirectTransport chuyển mọi request sang server test, giữ nguyên đường dẫn và query.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = t.target.Scheme, t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// serveFixtures phục vụ testdata/<site>/: trang danh sách tại ListURL, mọi đường dẫn khác
+// là trang chi tiết. Client dùng chung được chuyển sang server nên URL bóc được vẫn là URL thật.
+func serveFixtures(t *testing.T, s *Site) {
+	t.Helper()
+	dir := filepath.Join("testdata", s.Name)
+	list, err := url.Parse(s.ListURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		name := FixtureDetail
+		if r.URL.RequestURI() == list.RequestURI() {
+			name = FixtureList
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		http.ServeFile(w, r, filepath.Join(dir, name))
+	}))
+	t.Cleanup(srv.Close)
+
+	withFetcher(t, newFetchScheduler(8, 8, 0))
+	withRobots(t)
+	target, _ := url.Parse(srv.URL)
+	prev := client.Transport
+	client.Transport = redirectTransport{target: target}
+	t.Cleanup(func() { client.Transport = prev })
+	t.Cleanup(helpers.SetClock(func() time.Time { return fixtureNow }))
+}
+
+// withFetcher thay bộ điều phối tải trang dùng chung trong một test.
+func withFetcher(t *testing.T, f *fetchScheduler) {
+	t.Helper()
+	sharedFetcher() // khởi tạo trước để lần gọi sau không ghi đè f
+	prev := fetcher
+	fetcher = f
+	t.Cleanup(func() { fetcher = prev })
+}
+
+// withRobots dùng bộ nhớ robots.txt riêng cho một test, vì các test dùng chung host thật.
+func withRobots(t *testing.T) {
+	t.Helper()
+	prev := robots
+	robots = &robotsCache{entries: map[string]*robotsEntry{}}
+	t.Cleanup(func() { robots = prev })
+}
+
+type goldenItem struct {
+	Title     string `json:"title"`
+	URL       string `json:"url"`
+	Published string `json:"published,omitempty"`
+}
+
+type goldenDetail struct {
+	goldenItem
+	HTML        string              `json:"html"`
+	Text        string              `json:"text"`
+	Recruitment extract.Recruitment `json:"recruitment"`
+}
+
+type golden struct {
+	Items  []goldenItem `json:"items"`
+	Detail goldenDetail `json:"detail"`
+}
+
+func toGolden(a Article) goldenItem {
+	g := goldenItem{Title: a.Title, URL: a.URL}
+	if !a.Published.IsZero() {
+		g.Published = a.Published.Format(time.RFC3339)
+	}
+	return g
+}
+
+// TestSiteFixtures bóc trang danh sách và trang chi tiết của tin đầu tiên từ testdata
+// rồi so với testdata/<site>/golden.json. Chạy với -update để ghi lại file golden.
+// Trang mẫu hiện có là trang tự dựng theo selector, chưa phải bản lưu từ site thật, nên test
+// chỉ giữ cho cách bóc không đổi ngoài ý muốn. Cần thay bằng trang tải về bằng
+// crawler fixtures refresh thì mới phát hiện được site đổi giao diện.
+func TestSiteFixtures(t *testing.T) {
+	for _, s := range registry {
+		t.Run(s.Name, func(t *testing.T) {
+			serveFixtures(t, s)
+			ctx := context.Background()
+
+			items, err := s.listItems(ctx, s.ListURL())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) == 0 {
+				t.Fatal("trang danh sách không có tin nào")
+			}
+			var got golden
+			for _, a := range items {
+				got.Items = append(got.Items, toGolden(a))
+			}
+
+			a, err := s.fetchDetail(ctx, items[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			got.Detail = goldenDetail{goldenItem: toGolden(a), HTML: a.HTML, Text: a.Text, Recruitment: a.Recruitment}
+
+			data, err := json.MarshalIndent(got, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, '\n')
+
+			path := filepath.Join("testdata", s.Name, "golden.json")
+			if *update {
+				if err := os.WriteFile(path, data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (chạy go test ./sites -update để tạo)", err)
+			}
+			if !bytes.Equal(data, want) {
+				t.Errorf("kết quả khác %s, chạy go test ./sites -update nếu thay đổi là đúng\n--- got\n%s", path, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package sites
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"sync"
+	"webcrawler/config"
+	"webcrawler/logging"
+)
+
+// ErrSelectorMissing báo selector nội dung không khớp phần tử nào ở trang chi tiết.
+var ErrSelectorMissing = errors.New("không tìm thấy nội dung")
+
+// Stats là số liệu của một lần crawl một site.
+type Stats struct {
+	Pages         int `json:"pages"`          // trang danh sách đã tải
+	ListItems     int `json:"list_items"`     // tin bóc được trên trang danh sách
+	New           int `json:"new"`            // tin qua bộ lọc và chưa gửi
+	DetailOK      int `json:"detail_ok"`      // trang chi tiết bóc được nội dung
+	DetailMissing int `json:"detail_missing"` // trang chi tiết không khớp selector nội dung
+	Sent          int `json:"sent"`
+	Failed        int `json:"failed"`
+	Updated       int `json:"updated"`    // tin đã gửi bị sửa, đã gửi email cập nhật
+	Duplicates    int `json:"duplicates"` // tin trùng với tin của site khác, gộp vào email của tin đó
+	// Unchanged khi trang danh sách không đổi từ lần chạy trước nên không xử lý lại
+	Unchanged bool `json:"unchanged,omitempty"`
+}
+
+// String trả về số liệu dạng đọc được.
+func (s Stats) String() string {
+	out := fmt.Sprintf("%d tin trên danh sách, %d tin mới, %d đã gửi, %d lỗi nội dung, %d lỗi khác",
+		s.ListItems, s.New, s.Sent, s.DetailMissing, s.Failed)
+	if s.Unchanged {
+		out = "danh sách không đổi, bỏ qua"
+	}
+	if s.Duplicates > 0 {
+		out += fmt.Sprintf(", %d tin trùng", s.Duplicates)
+	}
+	if s.Updated > 0 {
+		out += fmt.Sprintf(", %d tin cập nhật", s.Updated)
+	}
+	return out
+}
+
+// LogValue ghi số liệu thành nhóm thuộc tính khi log bằng slog.
+func (s Stats) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("pages", s.Pages),
+		slog.Int("list_items", s.ListItems),
+		slog.Int("new", s.New),
+		slog.Int("detail_ok", s.DetailOK),
+		slog.Int("detail_missing", s.DetailMissing),
+		slog.Int("sent", s.Sent),
+		slog.Int("failed", s.Failed),
+		slog.Int("updated", s.Updated),
+		slog.Int("duplicates", s.Duplicates),
+		slog.Bool("unchanged", s.Unchanged),
+	)
+}
+
+// runStats gom số liệu từ các goroutine tải trang chi tiết.
+type runStats struct {
+	mu sync.Mutex
+	Stats
+}
+
+func (r *runStats) add(f func(s *Stats)) {
+	r.mu.Lock()
+	f(&r.Stats)
+	r.mu.Unlock()
+}
+
+func (r *runStats) snapshot() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Stats
+}
+
+// số lần chạy liên tiếp lỗi selector trước khi cảnh báo, ghi đè bằng SELECTOR_ALERT_RUNS
+const defaultSelectorAlertRuns = 3
+
+// checkSelectors ghi nhận kết quả lần chạy và gửi cảnh báo khi trang danh sách không có tin
+// hoặc selector nội dung trang chi tiết không khớp K lần liên tiếp. Mỗi chuỗi lỗi chỉ cảnh báo một lần.
+// Lần chạy trang danh sách không đổi không bóc tin nào nên không tính, bộ đếm giữ nguyên.
+func (s *Site) checkSelectors(ctx context.Context, st Stats) {
+	if st.Unchanged {
+		return
+	}
+	detailChecked := st.DetailOK+st.DetailMissing > 0
+	detailBroken := st.DetailMissing > 0 && st.DetailOK == 0
+	h, err := config.UpdateSiteHealth(s.Name, st.ListItems == 0, detailChecked, detailBroken)
+	if err != nil {
+		logging.From(ctx).Warn("lỗi cập nhật tình trạng site", logging.KeyStage, "health", "error", err)
+		return
+	}
+
+	k := config.GetEnvInt("SELECTOR_ALERT_RUNS", defaultSelectorAlertRuns)
+	if h.EmptyRuns == k {
+		s.alert(ctx, fmt.Sprintf("⚠️ [%s] Trang danh sách không có tin %d lần liên tiếp", s.Name, k),
+			fmt.Sprintf("<p>Trang <a href=\"%s\">%s</a> không trả về tin nào trong %d lần chạy liên tiếp. "+
+				"Có thể site đã đổi giao diện, cần kiểm tra lại selector.</p>",
+				html.EscapeString(s.ListURL()), html.EscapeString(s.ListURL()), k))
+	}
+	if h.DetailMissRuns == k {
+		s.alert(ctx, fmt.Sprintf("⚠️ [%s] Không bóc được nội dung trang chi tiết %d lần liên tiếp", s.Name, k),
+			fmt.Sprintf("<p>Selector nội dung trang chi tiết của site <b>%s</b> không khớp trong %d lần chạy liên tiếp "+
+				"(lần gần nhất %d trang lỗi). Cần kiểm tra lại selector.</p>",
+				html.EscapeString(s.Name), k, st.DetailMissing))
+	}
+}
+
+func (s *Site) alert(ctx context.Context, subject, body string) {
+	logger := logging.From(ctx)
+	logger.Warn("gửi cảnh báo selector", logging.KeyStage, "health", "subject", subject)
+	if err := config.SendEmail(subject, body); err != nil {
+		logger.Error("lỗi khi gửi email cảnh báo", logging.KeyStage, "health", "error", err)
+	}
+}
@@ -0,0 +1,89 @@
+package sites
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func selection(t *testing.T, html, selector string) *goquery.Selection {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc.Find(selector).First()
+}
+
+func TestTransformHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "không có placeholder",
+			input: `<table class="table-detail"><tr><td>Số hiệu</td></tr></table>`,
+			want:  `<table class="table-detail"><tr><td>Số hiệu</td></tr></table>`,
+		},
+		{
+			name:  "placeholder không có _files",
+			input: `<table><tr><td id="file-placeholder"><script>render();</script></td></tr></table>`,
+			want:  `<table><tr><td id="file-placeholder"><script>render();</script></td></tr></table>`,
+		},
+		{
+			name:  "thay script bằng danh sách link",
+			input: `<table><tr><td id="file-placeholder"><script>var _files = [{"FileName":"a.pdf","FileUrl":"https://x/a.pdf"},{"FileName":"b.doc","FileUrl":"https://x/b.doc"}];</script></td></tr></table>`,
+			want: `<html><head></head><body><table><tbody><tr><td id="file-placeholder">` +
+				`<a href="https://x/a.pdf" target="_blank" rel="noopener">a.pdf</a><br/>` +
+				`<a href="https://x/b.doc" target="_blank" rel="noopener">b.doc</a></td></tr></tbody></table></body></html>`,
+		},
+		{
+			name:    "_files không phải JSON",
+			input:   `<table><tr><td id="file-placeholder"><script>var _files = [{FileName: 'a.pdf'}];</script></td></tr></table>`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TransformHTML(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateLinkBeforeSend(t *testing.T) {
+	sel := selection(t, `<div class="news-other"><a href="/files/a.pdf">a</a><a href="https://moj.gov.vn/">b</a><a>c</a></div>`, ".news-other")
+	got, err := updateLinkBeforeSend(sel, "https://hocvientuphap.edu.vn/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<div class="news-other"><a href="https://hocvientuphap.edu.vn/files/a.pdf">a</a><a href="https://moj.gov.vn/">b</a><a>c</a></div>`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUpdateTableBeforeSendEmail(t *testing.T) {
+	sel := selection(t, `<table class="table table-bordered"><tr><td><a href="https://vca.org.vn/x">x</a></td>`+
+		`<td><a href="/upload/upload/files/tb.pdf">tb.pdf</a></td></tr></table>`, "table")
+	html, title, err := updateTableBeforeSendEmail(sel, "https://vca.org.vn/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "tb.pdf" {
+		t.Errorf("title = %q, want %q", title, "tb.pdf")
+	}
+	want := `<table class="table table-bordered"><tbody><tr><td><a href="https://vca.org.vn/x">x</a></td>` +
+		`<td><a href="https://vca.org.vn/upload/files/tb.pdf">tb.pdf</a></td></tr></tbody></table>`
+	if html != want {
+		t.Errorf("got\n%s\nwant\n%s", html, want)
+	}
+}
@@ -0,0 +1,270 @@
+package sites
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"webcrawler/config"
+	"webcrawler/helpers"
+	"webcrawler/logging"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// mặc định kiểm tra lại tin đã gửi trong 14 ngày, mỗi tin tối đa một lần mỗi ngày
+const (
+	defaultUpdateWindowDays = 14
+	defaultRecheckInterval  = 24 * time.Hour
+)
+
+// recheckSent tải lại các tin của site đã gửi trong UPDATE_WINDOW_DAYS ngày và chưa được kiểm tra
+// trong RECHECK_INTERVAL, gửi email cập nhật nếu nội dung hoặc tệp đính kèm thay đổi.
+// UPDATE_WINDOW_DAYS=0 là tắt. Chạy thử và nguồn feed không có trang chi tiết không kiểm tra lại.
+func (s *Site) recheckSent(ctx context.Context, opts Options, stats *runStats) {
+	days := config.GetEnvInt("UPDATE_WINDOW_DAYS", defaultUpdateWindowDays)
+	if opts.DryRun || days <= 0 || ctx.Err() != nil || !s.hasDetailPage() {
+		return
+	}
+	now := helpers.Now()
+	interval := config.GetEnvDuration("RECHECK_INTERVAL", defaultRecheckInterval)
+	articles, err := config.ArticlesToRecheck(s.Name, now.AddDate(0, 0, -days), now.Add(-interval))
+	if err != nil {
+		logging.From(ctx).Warn("không đọc được tin cần kiểm tra lại", logging.KeyStage, "recheck", "error", err)
+		return
+	}
+	for _, old := range articles {
+		if ctx.Err() != nil {
+			return
+		}
+		s.recheck(ctx, old, stats)
+	}
+}
+
+// recheck so tin đã gửi với trang hiện tại, lỗi tải trang chỉ ghi log để lần chạy sau thử lại.
+func (s *Site) recheck(ctx context.Context, old config.Article, stats *runStats) {
+	ctx = logging.With(ctx, logging.KeyURL, old.URL)
+	logger := logging.From(ctx)
+	a, err := s.fetchDetail(ctx, Article{Site: s.Name, Title: old.Title, URL: old.URL, Published: old.PublishedAt})
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Warn("không kiểm tra lại được tin đã gửi", logging.KeyStage, "recheck", "error", err)
+		}
+		return
+	}
+
+	hash := contentHash(a.HTML)
+	// tính lại từ nội dung đã gửi để hash không lệch khi cách bỏ phần khung trang thay đổi,
+	// hash đã lưu chỉ dùng khi không lưu nội dung
+	oldHash := old.ContentHash
+	if old.Content != "" {
+		oldHash = contentHash(old.Content)
+	}
+	if hash == oldHash {
+		if err := config.MarkArticleChecked(old.URL, hash); err != nil {
+			logger.Warn("không ghi được lần kiểm tra lại", logging.KeyStage, "recheck", "error", err)
+		}
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	change := diffArticle(old.Content, a.HTML)
+	err = config.SendEmail("[Cập nhật] "+a.Subject(), change.HTML()+a.Recruitment.HTML()+a.HTML, a.attachments()...)
+	if err != nil {
+		logger.Error("lỗi khi gửi email cập nhật", logging.KeyStage, "recheck", "error", err)
+		return
+	}
+	config.SaveArticle(a.record())
+	// lịch nhắc đặt lại sẽ gửi lại cả lời nhắc đã gửi, chỉ làm khi hạn nộp đổi
+	if !sameDeadline(old.Recruitment.Deadline, a.Recruitment.Deadline) {
+		scheduleReminders(a)
+	}
+	stats.add(func(st *Stats) { st.Updated++ })
+	logger.Info("đã gửi email cập nhật", logging.KeyStage, "recheck",
+		"lines_changed", change.changedLines(), "attachments_added", len(change.added), "attachments_removed", len(change.removed))
+}
+
+func sameDeadline(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// contentHash là hash của nội dung thông báo dạng text (bỏ khác biệt khoảng trắng, thẻ HTML
+// và phần khung trang, xem noticeBody) cùng danh sách tệp đính kèm.
+func contentHash(content string) string {
+	body := noticeBody(content)
+	sum := sha256.Sum256([]byte(noticeText(body) + "\n\n" + strings.Join(attachmentLinks(body), "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// phần khung trang hay nằm lẫn trong vùng nội dung: tin liên quan, cột bên, nút chia sẻ, bình luận,
+// bộ đếm lượt xem. Các phần này đổi sau mỗi lần có tin mới hoặc người xem nên không tính là sửa tin.
+const chromeSelector = "script, style, form, nav, aside, " +
+	"[class*=related], [id*=related], [class*=lien-quan], [class*=tinlienquan], [class*=other-news], [class*=tin-khac], " +
+	"[class*=sidebar], [id*=sidebar], [class*=share], [class*=social], [class*=comment], [class*=breadcrumb], " +
+	"[class*=views], [class*=view-count], [class*=viewcount], [class*=luot-xem], [class*=luotxem], [class*=counter]"
+
+var (
+	// thẻ tiêu đề (h1-h6) mở đầu danh sách tin khác ở cuối bài, bỏ cả tiêu đề và mọi thứ sau nó.
+	// Đoạn văn thường có thể là một dòng của thông báo ("Thông báo khác:") nên không xét
+	relatedHeadingRe = regexp.MustCompile(`(?i)^(?:các\s+)?(?:tin|bài|bài viết|tin tức|thông báo)\s+(?:liên quan|khác|cùng chuyên mục|mới hơn|cũ hơn|đã đưa)\s*:?$`)
+	// "Lượt xem: 1.234", "Số lần xem 56" đứng lẫn trong dòng ngày đăng
+	viewCountRe = regexp.MustCompile(`(?i)(?:số\s+)?(?:lượt\s+xem|lượt\s+truy\s+cập|lần\s+xem)\s*:?\s*[\d.,]+`)
+)
+
+// noticeBody bỏ phần khung trang khỏi nội dung trang chi tiết, chỉ giữ phần thông báo.
+func noticeBody(content string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+	doc.Find(chromeSelector).Remove()
+	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(_ int, sel *goquery.Selection) {
+		if relatedHeadingRe.MatchString(strings.TrimSpace(sel.Text())) {
+			sel.NextAll().Remove()
+			sel.Remove()
+		}
+	})
+	body, err := doc.Find("body").Html()
+	if err != nil {
+		return content
+	}
+	return body
+}
+
+// noticeText là text của nội dung đã bỏ khung trang, không có bộ đếm lượt xem và dòng trống.
+func noticeText(body string) string {
+	var lines []string
+	for _, line := range strings.Split(helpers.HTMLToText(body), "\n") {
+		if viewCountRe.MatchString(line) {
+			// bỏ cả dấu phân cách còn thừa, vd "05/03/2025 | Lượt xem: 12"
+			line = strings.Trim(strings.TrimSpace(viewCountRe.ReplaceAllString(line, "")), "|-– ")
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var attachmentRe = regexp.MustCompile(`(?i)\.(pdf|docx?|xlsx?|pptx?|zip|rar|7z)(\?|#|$)`)
+
+// attachmentLinks trả về các link tới tệp (pdf, doc, xls, ...) trong nội dung, đã sắp xếp.
+func attachmentLinks(content string) []string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return nil
+	}
+	var links []string
+	doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		if href := strings.TrimSpace(a.AttrOr("href", "")); attachmentRe.MatchString(href) {
+			links = append(links, href)
+		}
+	})
+	slices.Sort(links)
+	return slices.Compact(links)
+}
+
+// articleChange là khác biệt giữa hai bản nội dung của một tin.
+type articleChange struct {
+	lines          []helpers.DiffLine
+	added, removed []string // tệp đính kèm
+}
+
+// diffArticle so hai bản nội dung của tin, bỏ qua phần khung trang như contentHash.
+func diffArticle(oldContent, newContent string) articleChange {
+	oldBody, newBody := noticeBody(oldContent), noticeBody(newContent)
+	c := articleChange{lines: helpers.DiffLines(
+		strings.Split(noticeText(oldBody), "\n"),
+		strings.Split(noticeText(newBody), "\n"),
+	)}
+	oldLinks, newLinks := attachmentLinks(oldBody), attachmentLinks(newBody)
+	for _, l := range newLinks {
+		if !slices.Contains(oldLinks, l) {
+			c.added = append(c.added, l)
+		}
+	}
+	for _, l := range oldLinks {
+		if !slices.Contains(newLinks, l) {
+			c.removed = append(c.removed, l)
+		}
+	}
+	return c
+}
+
+func (c articleChange) changedLines() int {
+	n := 0
+	for _, l := range c.lines {
+		if l.Op != helpers.DiffEqual {
+			n++
+		}
+	}
+	return n
+}
+
+// số dòng không đổi hiển thị quanh mỗi chỗ sửa
+const diffContext = 1
+
+// HTML trình bày các dòng bị xóa/thêm (kèm dòng xung quanh) và tệp đính kèm thay đổi.
+func (c articleChange) HTML() string {
+	var b strings.Builder
+	b.WriteString(`<p><b>Tin đã được sửa sau khi gửi.</b></p>`)
+	if c.changedLines() > 0 {
+		b.WriteString(`<h3>Nội dung thay đổi</h3><div style="font-family:monospace;white-space:pre-wrap;border:1px solid #ccc;padding:8px;margin-bottom:12px">`)
+		skipped := false
+		for i, l := range c.lines {
+			if l.Op == helpers.DiffEqual && !c.nearChange(i) {
+				skipped = true
+				continue
+			}
+			if skipped {
+				b.WriteString(`<div style="color:#888">…</div>`)
+				skipped = false
+			}
+			text := html.EscapeString(l.Text)
+			switch l.Op {
+			case helpers.DiffDelete:
+				fmt.Fprintf(&b, `<div style="background:#fdd"><del>- %s</del></div>`, text)
+			case helpers.DiffInsert:
+				fmt.Fprintf(&b, `<div style="background:#dfd">+ %s</div>`, text)
+			default:
+				fmt.Fprintf(&b, `<div>&nbsp; %s</div>`, text)
+			}
+		}
+		if skipped {
+			b.WriteString(`<div style="color:#888">…</div>`)
+		}
+		b.WriteString(`</div>`)
+	}
+	if len(c.added)+len(c.removed) > 0 {
+		b.WriteString(`<h3>Tệp đính kèm thay đổi</h3><ul>`)
+		for _, l := range c.added {
+			fmt.Fprintf(&b, `<li>Mới: <a href="%s">%s</a></li>`, html.EscapeString(l), html.EscapeString(l))
+		}
+		for _, l := range c.removed {
+			fmt.Fprintf(&b, `<li>Đã gỡ: <del>%s</del></li>`, html.EscapeString(l))
+		}
+		b.WriteString(`</ul>`)
+	}
+	b.WriteString(`<hr>`)
+	return b.String()
+}
+
+// nearChange cho biết dòng i có cách dòng bị sửa không quá diffContext dòng.
+func (c articleChange) nearChange(i int) bool {
+	for j := max(0, i-diffContext); j <= min(len(c.lines)-1, i+diffContext); j++ {
+		if c.lines[j].Op != helpers.DiffEqual {
+			return true
+		}
+	}
+	return false
+}
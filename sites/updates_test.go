@@ -0,0 +1,160 @@
+package sites
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestContentHash(t *testing.T) {
+	base := `<p>Hạn nộp hồ sơ: 20/03/2025</p><p><a href="https://x.vn/tb.pdf">Thông báo</a></p>`
+	tests := []struct {
+		name    string
+		content string
+		changed bool
+	}{
+		{"chỉ khác khoảng trắng và thẻ", `<div>Hạn nộp   hồ sơ: 20/03/2025</div><div><a class="f" href="https://x.vn/tb.pdf">Thông báo</a></div>`, false},
+		{"gia hạn", `<p>Hạn nộp hồ sơ: 30/03/2025</p><p><a href="https://x.vn/tb.pdf">Thông báo</a></p>`, true},
+		{"thay tệp đính kèm", `<p>Hạn nộp hồ sơ: 20/03/2025</p><p><a href="https://x.vn/tb-v2.pdf">Thông báo</a></p>`, true},
+	}
+	for _, tt := range tests {
+		if got := contentHash(tt.content) != contentHash(base); got != tt.changed {
+			t.Errorf("%s: changed = %v, muốn %v", tt.name, got, tt.changed)
+		}
+	}
+}
+
+// Phần khung trang (lượt xem, tin liên quan, cột bên) đổi nhưng thân thông báo giữ nguyên thì không phải tin bị sửa.
+func TestContentHashIgnoresChrome(t *testing.T) {
+	page := func(views, body, related, sidebar string) string {
+		return `<div class="blog-page">
+			<div class="date">05/03/2025 | Lượt xem: ` + views + `</div>
+			<h1>Thông báo tuyển dụng viên chức năm 2025</h1>
+			<p>` + body + `</p>
+			<p><a href="/files/tb.pdf">Thông báo</a></p>
+			<div class="share"><a href="https://facebook.com/sharer?u=x">Chia sẻ</a></div>
+			<h3>Tin liên quan</h3>
+			<ul><li><a href="/tb-2.html">` + related + `</a> (` + sidebar + `)</li></ul>
+		</div>
+		<div class="sidebar-news"><span>` + sidebar + `</span> Tin mới nhất</div>`
+	}
+	base := page("120", "Hạn nộp hồ sơ: 20/03/2025", "Thông báo nghỉ lễ", "01/03/2025")
+
+	chrome := page("4.567", "Hạn nộp hồ sơ: 20/03/2025", "Kết quả xét tuyển đợt 1", "10/03/2025")
+	if contentHash(chrome) != contentHash(base) {
+		t.Error("chỉ đổi lượt xem, tin liên quan, cột bên mà hash thay đổi")
+	}
+	if c := diffArticle(base, chrome); c.changedLines() != 0 || len(c.added)+len(c.removed) != 0 {
+		t.Errorf("chỉ đổi khung trang mà có thay đổi:\n%s", c.HTML())
+	}
+
+	body := page("4.567", "Hạn nộp hồ sơ: 30/03/2025", "Kết quả xét tuyển đợt 1", "10/03/2025")
+	if contentHash(body) == contentHash(base) {
+		t.Error("gia hạn nộp hồ sơ mà hash không đổi")
+	}
+	c := diffArticle(base, body)
+	if c.changedLines() != 2 {
+		t.Errorf("changedLines = %d, muốn 2:\n%s", c.changedLines(), c.HTML())
+	}
+	for _, unwanted := range []string{"Lượt xem", "Tin liên quan", "Kết quả xét tuyển", "Chia sẻ"} {
+		if strings.Contains(c.HTML(), unwanted) {
+			t.Errorf("phần khung trang %q nằm trong email cập nhật:\n%s", unwanted, c.HTML())
+		}
+	}
+}
+
+// Cụm "Thông báo khác" nằm trong thân bài không phải tiêu đề danh sách tin khác, phần sau nó vẫn là thông báo.
+func TestNoticeBodyKeepsPhraseMidBody(t *testing.T) {
+	content := `<div class="content"><p>Hội đồng tuyển dụng thông báo lịch thi như sau.</p>
+		<p><strong>Thông báo khác:</strong></p>
+		<p>Thí sinh mang theo căn cước công dân.</p>
+		<div>Thông báo khác</div><span>Hạn nộp hồ sơ: 20/03/2025</span>
+		<h3>Thông báo khác</h3><ul><li>Lịch nghỉ lễ</li></ul></div>`
+	text := noticeText(noticeBody(content))
+	for _, want := range []string{"Thông báo khác:", "Thí sinh mang theo căn cước công dân.", "Hạn nộp hồ sơ: 20/03/2025"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("thiếu %q trong\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Lịch nghỉ lễ") {
+		t.Errorf("danh sách sau tiêu đề tin khác vẫn còn:\n%s", text)
+	}
+}
+
+func TestDiffArticle(t *testing.T) {
+	old := `<p>Thông báo tuyển dụng</p><p>Chỉ tiêu: 5</p><p>Hạn nộp hồ sơ: 20/03/2025</p><p>Liên hệ: phòng TCCB</p>
+		<p>Dòng 5</p><p>Dòng 6</p><p>Dòng 7</p><p><a href="/files/tb.pdf">TB</a> <a href="/files/mau.docx">Mẫu</a></p>`
+	updated := `<p>Thông báo tuyển dụng</p><p>Chỉ tiêu: 5</p><p>Hạn nộp hồ sơ: 30/03/2025</p><p>Liên hệ: phòng TCCB</p>
+		<p>Dòng 5</p><p>Dòng 6</p><p>Dòng 7</p><p><a href="/files/tb-giahan.pdf">TB</a> <a href="/files/mau.docx">Mẫu</a></p>`
+	c := diffArticle(old, updated)
+	if n := c.changedLines(); n != 2 {
+		t.Errorf("changedLines = %d, muốn 2", n)
+	}
+	if len(c.added) != 1 || c.added[0] != "/files/tb-giahan.pdf" || len(c.removed) != 1 || c.removed[0] != "/files/tb.pdf" {
+		t.Errorf("tệp đính kèm: thêm %v, gỡ %v", c.added, c.removed)
+	}
+
+	got := c.HTML()
+	for _, want := range []string{
+		"<del>- Hạn nộp hồ sơ: 20/03/2025</del>",
+		"+ Hạn nộp hồ sơ: 30/03/2025",
+		"&nbsp; Chỉ tiêu: 5", // dòng ngay trước chỗ sửa
+		"Mới: <a href=\"/files/tb-giahan.pdf\">",
+		"Đã gỡ: <del>/files/tb.pdf</del>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("thiếu %q trong\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Dòng 6") {
+		t.Errorf("dòng xa chỗ sửa không được hiển thị:\n%s", got)
+	}
+}
+
+// Tin seed --store-content lưu lại chưa từng được gửi nên phải được đánh dấu để không bị kiểm tra lại.
+func TestSeedStoredArticleMarkedSeeded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			http.NotFound(w, r)
+		case "/list":
+			w.Write([]byte(`<a href="/tb">Thông báo tuyển dụng</a>`))
+		default:
+			w.Write([]byte(`<div class="content"><p>Hạn nộp hồ sơ: 20/03/2025</p></div>`))
+		}
+	}))
+	defer srv.Close()
+	withFetcher(t, newFetchScheduler(8, 8, 0))
+	withRobots(t)
+	db := withFakeDB(t, nil)
+	s := &Site{
+		Name: "test", BaseURL: srv.URL + "/", ListPath: "list",
+		ParseList: func(s *Site, doc *goquery.Document) []Article {
+			href, _ := doc.Find("a").Attr("href")
+			return []Article{{Title: doc.Find("a").Text(), URL: srv.URL + href}}
+		},
+		ParseDetail: func(s *Site, doc *goquery.Document, a *Article) error {
+			a.HTML, _ = goquery.OuterHtml(doc.Find(".content"))
+			return nil
+		},
+	}
+
+	res, err := s.Seed(context.Background(), 1, true, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Stored != 1 {
+		t.Fatalf("seed: %v", res)
+	}
+	saved := db.argsOf("INSERT INTO articles")
+	if len(saved) != 1 {
+		t.Fatalf("lưu %d tin, muốn 1", len(saved))
+	}
+	if seeded := saved[0][len(saved[0])-1]; seeded != true {
+		t.Errorf("tin seed lưu với seeded = %v, muốn true", seeded)
+	}
+}
@@ -1,95 +1,52 @@
 package sites
 
 import (
-	"crypto/tls"
+	"errors"
 	"fmt"
-	"github.com/PuerkitoBio/goquery"
-	"log"
-	"net/http"
 	"strings"
-	"sync"
 
-	"webcrawler/config"
+	"github.com/PuerkitoBio/goquery"
 )
 
-func GetDocs() {
-	baseURL := "https://vca.org.vn/"
-	url := baseURL + "frontend/home/search?s=Th%C3%B4ng+b%C3%A1o+tuy%E1%BB%83n+d%E1%BB%A5ng&loaivanban=&issuing_agency=&year=&submit=T%C3%ACm+ki%E1%BA%BFm"
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	resp, err := http.Get(url)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer resp.Body.Close()
-
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		log.Fatalf("Lỗi khi phân tích HTML: %v", err)
-	}
-
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, 5)
-
-	doc.Find("table.table-bordered tbody tr td a").Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if exists {
-			detailURL := baseURL + href
-			fmt.Printf("Link %d: %s\n", i+1, detailURL)
-			if config.IsLinkSent(detailURL) {
-				log.Printf("✅ Đã gửi: %s\n", detailURL)
-				return
+var vcaDocsSite = &Site{
+	Name:     "vca_docs",
+	BaseURL:  "https://vca.org.vn/",
+	ListPath: "frontend/home/search?s=Th%C3%B4ng+b%C3%A1o+tuy%E1%BB%83n+d%E1%BB%A5ng&loaivanban=&issuing_agency=&year=&submit=T%C3%ACm+ki%E1%BA%BFm",
+	Undated:  UndatedSend,
+	ParseList: func(s *Site, doc *goquery.Document) []Article {
+		var items []Article
+		doc.Find("table.table-bordered tbody tr td a").Each(func(i int, sel *goquery.Selection) {
+			href, exists := sel.Attr("href")
+			if exists {
+				items = append(items, Article{
+					Title:     sel.Text(),
+					URL:       s.BaseURL + href,
+					Published: dateFromSelection(sel.Closest("tr")),
+				})
 			}
-			sem <- struct{}{}
-			wg.Add(1)
-			go func(url string) {
-				defer wg.Done()
-				defer func() { <-sem }() // release slot
-				log.Printf("🔍 Đang crawl: %s\n", url)
-				crawlDocDetail(url, baseURL)
-			}(detailURL)
+		})
+		return items
+	},
+	ParseDetail: func(s *Site, doc *goquery.Document, a *Article) error {
+		tableSelection := doc.Find("table.table.table-bordered").First()
+		if tableSelection.Length() == 0 {
+			return fmt.Errorf("%w (table.table.table-bordered)", ErrSelectorMissing)
 		}
-	})
-	wg.Wait()
-}
 
-func crawlDocDetail(detailURL string, baseURL string) {
-	resp, err := http.Get(detailURL)
-	if err != nil {
-		log.Println("Lỗi khi tải trang chi tiết:", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	docDetail, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		log.Println("Lỗi khi phân tích HTML chi tiết:", err)
-		return
-	}
-
-	tableSelection := docDetail.Find("table.table.table-bordered").First()
-	if tableSelection.Length() == 0 {
-		log.Println("⚠️ Không tìm thấy bảng")
-		return
-	}
-
-	tableHTML, emailTitle, err := updateTableBeforeSendEmail(tableSelection, baseURL)
-
-	if err != nil {
-		log.Println("Lỗi khi lấy HTML bảng:", err)
-		return
-	}
-
-	if tableHTML == "" {
-		log.Println("Không tìm thấy bảng để gửi email")
-		return
-	}
-
-	err = config.SendEmail(emailTitle, tableHTML)
-	if err != nil {
-		log.Println("Lỗi khi gửi email:", err)
-		return
-	}
-	config.MarkLinkAsSent(detailURL)
+		tableHTML, emailTitle, err := updateTableBeforeSendEmail(tableSelection, s.BaseURL)
+		if err != nil {
+			return err
+		}
+		if tableHTML == "" {
+			return errors.New("không tìm thấy bảng để gửi email")
+		}
+		if a.Published.IsZero() {
+			a.Published = dateFromSelection(tableSelection)
+		}
+		a.Title = emailTitle
+		a.HTML = tableHTML
+		return nil
+	},
 }
 
 func updateTableBeforeSendEmail(tableSelection *goquery.Selection, baseURL string) (string, string, error) {
@@ -106,7 +63,6 @@ func updateTableBeforeSendEmail(tableSelection *goquery.Selection, baseURL strin
 
 	tableHTML, err := goquery.OuterHtml(tableSelection)
 	if err != nil {
-		log.Println("Lỗi lấy HTML bảng:", err)
 		return "", "", err
 	}
 	return tableHTML, emailTitle, nil
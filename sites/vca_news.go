@@ -1,102 +1,43 @@
 package sites
 
 import (
-	"crypto/tls"
 	"fmt"
+
 	"github.com/PuerkitoBio/goquery"
-	"log"
-	"net/http"
-	"strings"
-	"sync"
-	"webcrawler/config"
 )
 
-func GetNews() {
-	baseURL := "https://vca.org.vn/"
-	url := baseURL + "tin-vca-c28.html"
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	resp, err := http.Get(url)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer resp.Body.Close()
-
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		log.Fatalf("Lỗi khi phân tích HTML: %v", err)
-	}
-
-	keywords := []string{"kỳ thi", "tuyển dụng", "thí sinh"}
-
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, 5)
-
-	doc.Find(".title-5 a").Each(func(i int, s *goquery.Selection) {
-		title := s.Text()
-		if findKeyword(title, keywords) {
-			href, exists := s.Attr("href")
+var vcaNewsSite = &Site{
+	Name:               "vca_news",
+	BaseURL:            "https://vca.org.vn/",
+	ListPath:           "tin-vca-c28.html",
+	Keywords:           []string{"kỳ thi", "tuyển dụng", "thí sinh"},
+	Undated:            UndatedSend,
+	DetailDateSelector: ".date-news, .time-news, .date",
+	ParseList: func(s *Site, doc *goquery.Document) []Article {
+		var items []Article
+		doc.Find(".title-5 a").Each(func(i int, sel *goquery.Selection) {
+			href, exists := sel.Attr("href")
 			if exists {
-				detailURL := baseURL + href
-				fmt.Printf("Link %d: %s\n", i+1, detailURL)
-				if config.IsLinkSent(detailURL) {
-					log.Printf("✅ Đã gửi: %s\n", detailURL)
-					return
-				}
-				sem <- struct{}{}
-				wg.Add(1)
-				go func(url string) {
-					defer wg.Done()
-					defer func() { <-sem }() // release slot
-					log.Printf("🔍 Đang crawl: %s\n", url)
-					crawlNewsDetail(detailURL, title)
-				}(detailURL)
+				items = append(items, Article{
+					Title:     sel.Text(),
+					URL:       s.BaseURL + href,
+					Published: dateFromSelection(sel.Parent().Parent().Find(".date, .time").First()),
+				})
 			}
+		})
+		return items
+	},
+	ParseDetail: func(s *Site, doc *goquery.Document, a *Article) error {
+		contentSelection := doc.Find(".content-items").First()
+		if contentSelection.Length() == 0 {
+			return fmt.Errorf("%w (.content-items)", ErrSelectorMissing)
 		}
-	})
-	wg.Wait()
-}
 
-func findKeyword(s string, keywords []string) bool {
-	lower := strings.ToLower(s)
-	for _, kw := range keywords {
-		if strings.Contains(lower, kw) {
-			return true
+		contentHtml, err := goquery.OuterHtml(contentSelection)
+		if err != nil {
+			return err
 		}
-	}
-	return false
-}
-
-func crawlNewsDetail(detailURL string, emailTitle string) {
-	resp, err := http.Get(detailURL)
-	if err != nil {
-		log.Println("Lỗi khi tải trang chi tiết:", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	docDetail, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		log.Println("Lỗi khi phân tích HTML chi tiết:", err)
-		return
-	}
-
-	contentSelection := docDetail.Find(".content-items").First()
-	if contentSelection.Length() == 0 {
-		log.Println("⚠️ Không tìm thấy nội dung")
-		return
-	}
-
-	contentHtml, err := goquery.OuterHtml(contentSelection)
-
-	if err != nil {
-		log.Println("Lỗi khi lấy HTML content:", err)
-		return
-	}
-
-	err = config.SendEmail(emailTitle, contentHtml)
-	if err != nil {
-		log.Println("Lỗi khi gửi email:", err)
-		return
-	}
-	config.MarkLinkAsSent(detailURL)
+		a.HTML = contentHtml
+		return nil
+	},
 }